@@ -30,9 +30,6 @@ import (
 func main() {
 	logger.SetupBootstrap("warn")
 
-	appCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
 	flags, err := cli.ParseFlags()
 	if err != nil {
 		printErrorAndExit(err, 2)
@@ -59,7 +56,12 @@ func main() {
 		}
 		return
 	case flags.Validate:
-		if _, err = config.LoadAndValidate(flags.ConfigPath); err != nil {
+		cfg, err := config.LoadAndValidate(flags.ConfigPath)
+		if err != nil {
+			printErrorAndExit(err, 1)
+		}
+		// Sensor types and generated keys are checked against the registry.
+		if err = sensors.Prepare(&cfg); err != nil {
 			printErrorAndExit(err, 1)
 		}
 		return
@@ -68,10 +70,19 @@ func main() {
 		return
 	}
 
+	os.Exit(run(flags))
+}
+
+// run starts the agent and returns the exit code. It returns instead of
+// exiting, so deferred cleanup (log sinks, sensor sessions) always runs.
+func run(flags cli.CLI) int {
+	appCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg, err := config.LoadAndValidate(flags.ConfigPath)
 	if err != nil {
 		slog.Error("failed to load configuration", "err", err)
-		os.Exit(1)
+		return 1
 	}
 
 	extraHandlers := make([]slog.Handler, 0)
@@ -132,19 +143,20 @@ func main() {
 	err = sensors.Prepare(&cfg)
 	if err != nil {
 		slog.Error("failed to load sensors configuration", "err", err)
-		os.Exit(1)
+		return 1
 	}
 
 	sens, err := sensors.Build(cfg)
 	if err != nil {
 		slog.Error("failed to create sensors from configuration", "err", err)
-		os.Exit(1)
+		return 1
 	}
+	defer sens.Close()
 
 	btns, err := buttons.Build(cfg)
 	if err != nil {
 		slog.Error("failed to create sensors from configuration", "err", err)
-		os.Exit(1)
+		return 1
 	}
 
 	s := agent.Settings{
@@ -185,25 +197,26 @@ func main() {
 		pub = mqtt.New(o)
 	}
 
-	a, err := agent.New(s, sens, btns, pub)
+	a, err := agent.New(s, sens.Sensors, btns, pub)
 	if err != nil {
 		slog.Error("failed to initialize agent", "err", err)
-		os.Exit(1)
+		return 1
 	}
 
 	if flags.Purge {
 		if err := a.Purge(); err != nil {
 			slog.Error("purge failed", "err", err)
-			os.Exit(1)
+			return 1
 		}
 		slog.Info("purge completed")
-		return
+		return 0
 	}
 
 	if err = a.Run(appCtx); err != nil {
 		slog.Error("agent stopped with error", "err", err)
-		os.Exit(1)
+		return 1
 	}
+	return 0
 }
 
 func printErrorAndExit(err error, code int) {
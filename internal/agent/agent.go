@@ -86,7 +86,7 @@ func (a *agent) Run(ctx context.Context) error {
 
 	if a.once {
 		for _, group := range a.groupedSensors {
-			sensorsStateCache := make(map[string]string, len(group))
+			sensorsStateCache := make(map[string]publishedState, len(group))
 			a.collectAndPublishGroup(ctx, group, sensorsStateCache)
 		}
 		return nil
@@ -103,7 +103,7 @@ func (a *agent) Run(ctx context.Context) error {
 			ticker := time.NewTicker(interval)
 			defer ticker.Stop()
 
-			sensorsStateCache := make(map[string]string, len(group))
+			sensorsStateCache := make(map[string]publishedState, len(group))
 
 			a.collectAndPublishGroup(ctx, group, sensorsStateCache)
 			for {
@@ -129,7 +129,7 @@ func (a *agent) Purge() error {
 
 	for _, group := range a.groupedSensors {
 		for _, s := range group {
-			topic := fmt.Sprintf("%s/sensor/%s/%s/config", a.discoveryBase, a.deviceId, s.Key())
+			topic := fmt.Sprintf("%s/%s/%s/%s/config", a.discoveryBase, sensors.Component(s), a.deviceId, s.Key())
 			if err := a.pub.Publish(topic, 1, true, []byte{}); err != nil {
 				return fmt.Errorf("purge: clear discovery failed (topic=%s): %w", topic, err)
 			}
@@ -149,6 +149,18 @@ func (a *agent) Purge() error {
 			if err := a.pub.Publish(topic, 1, true, []byte{}); err != nil {
 				return fmt.Errorf("purge: clear state failed (topic=%s): %w", topic, err)
 			}
+
+			topic = fmt.Sprintf("%s/%s/availability", a.stateBase, s.Key())
+			if err := a.pub.Publish(topic, 1, true, []byte{}); err != nil {
+				return fmt.Errorf("purge: clear sensor availability failed (topic=%s): %w", topic, err)
+			}
+
+			if _, ok := s.(sensors.AttributesSensor); ok {
+				topic := fmt.Sprintf("%s/%s/attributes", a.stateBase, s.Key())
+				if err := a.pub.Publish(topic, 1, true, []byte{}); err != nil {
+					return fmt.Errorf("purge: clear attributes failed (topic=%s): %w", topic, err)
+				}
+			}
 		}
 	}
 
@@ -184,8 +196,8 @@ func (a *agent) handleButtonPress(ctx context.Context, b buttons.Button, topic s
 		"button", b.Key(),
 		"topic", topic,
 		"payload", string(payload),
-		"cmd", b.Command(),
 	}
+	attrs = append(attrs, b.LogAttrs()...)
 
 	if err != nil {
 		slog.Error("button command failed", append(attrs, "err", err, "output", string(out))...)
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/sensors"
+)
+
+type published struct {
+	topic   string
+	payload string
+}
+
+// fakePublisher records retained publishes.
+type fakePublisher struct {
+	msgs []published
+}
+
+func (p *fakePublisher) SetAvailability(string, []byte) {}
+func (p *fakePublisher) Connect(time.Duration) error    { return nil }
+func (p *fakePublisher) Close()                         {}
+func (p *fakePublisher) Subscribe(string, byte, func(string, []byte)) error {
+	return nil
+}
+
+func (p *fakePublisher) Publish(topic string, _ byte, _ bool, payload []byte) error {
+	p.msgs = append(p.msgs, published{topic: topic, payload: string(payload)})
+	return nil
+}
+
+// fakeSensor returns the next reading of a fixed sequence on each collection.
+type fakeSensor struct {
+	readings []sensors.Reading
+}
+
+func (s *fakeSensor) Key() string                          { return "temp" }
+func (s *fakeSensor) Name() string                         { return "Temp" }
+func (s *fakeSensor) Interval() time.Duration              { return time.Minute }
+func (s *fakeSensor) HA() *config.HASensorConfig           { return nil }
+func (s *fakeSensor) Precision() *int                      { return nil }
+func (s *fakeSensor) PublishPolicy() sensors.PublishPolicy { return sensors.PublishPolicy{} }
+
+func (s *fakeSensor) Collect(context.Context) (sensors.Reading, error) {
+	r := s.readings[0]
+	s.readings = s.readings[1:]
+	if !r.Available() {
+		return r, errors.New("read failed")
+	}
+	return r, nil
+}
+
+func TestCollectPublishesAvailability(t *testing.T) {
+	num := sensors.Reading{Kind: sensors.ReadingNumber, Value: 21.5, Precision: 1}
+	s := &fakeSensor{readings: []sensors.Reading{num, {}, {}, num}}
+
+	pub := &fakePublisher{}
+	a, err := New(Settings{StatePrefix: "gometrum", DeviceId: "host"}, []sensors.Sensor{s}, nil, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := make(map[string]publishedState)
+	for range 4 {
+		a.collectAndPublishGroup(context.Background(), []sensors.Sensor{s}, cache)
+	}
+
+	// A failed collection marks the entity offline and keeps the retained
+	// state; only changes of availability are published.
+	want := []published{
+		{topic: "gometrum/host/temp/availability", payload: "online"},
+		{topic: "gometrum/host/temp/state", payload: "21.5"},
+		{topic: "gometrum/host/temp/availability", payload: "offline"},
+		{topic: "gometrum/host/temp/availability", payload: "online"},
+	}
+	if !reflect.DeepEqual(pub.msgs, want) {
+		t.Errorf("published %+v, want %+v", pub.msgs, want)
+	}
+}
@@ -5,20 +5,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
+	"time"
 
 	"github.com/Miklakapi/gometrum/internal/sensors"
 )
 
 type haSensorDiscovery struct {
-	Name              string    `json:"name"`
-	UniqueID          string    `json:"unique_id"`
-	StateTopic        string    `json:"state_topic"`
-	AvailabilityTopic string    `json:"availability_topic,omitempty"`
-	Icon              string    `json:"icon,omitempty"`
-	Unit              string    `json:"unit_of_measurement,omitempty"`
-	DeviceClass       string    `json:"device_class,omitempty"`
-	StateClass        string    `json:"state_class,omitempty"`
-	Device            *haDevice `json:"device,omitempty"`
+	Name             string           `json:"name"`
+	UniqueID         string           `json:"unique_id"`
+	StateTopic       string           `json:"state_topic"`
+	AttributesTopic  string           `json:"json_attributes_topic,omitempty"`
+	Availability     []haAvailability `json:"availability,omitempty"`
+	AvailabilityMode string           `json:"availability_mode,omitempty"`
+	Icon             string           `json:"icon,omitempty"`
+	Unit             string           `json:"unit_of_measurement,omitempty"`
+	DeviceClass      string           `json:"device_class,omitempty"`
+	StateClass       string           `json:"state_class,omitempty"`
+	DisplayPrecision *int             `json:"suggested_display_precision,omitempty"`
+	ExpireAfter      int              `json:"expire_after,omitempty"`
+	Device           *haDevice        `json:"device,omitempty"`
+}
+
+type haAvailability struct {
+	Topic string `json:"topic"`
 }
 
 type haButtonDiscovery struct {
@@ -51,14 +61,26 @@ func (a *agent) publishDiscovery() error {
 			key := s.Key()
 
 			stateTopic := fmt.Sprintf("%s/%s/state", a.stateBase, key)
-			configTopic := fmt.Sprintf("%s/sensor/%s/%s/config", a.discoveryBase, a.deviceId, key)
+			component := sensors.Component(s)
+			configTopic := fmt.Sprintf("%s/%s/%s/%s/config", a.discoveryBase, component, a.deviceId, key)
 
+			// The entity is available only while the agent is online
+			// and its last collection produced a value.
 			payload := haSensorDiscovery{
-				Name:              s.Name(),
-				UniqueID:          fmt.Sprintf("%s_%s", a.deviceId, key),
-				StateTopic:        stateTopic,
-				AvailabilityTopic: a.availabilityTopic,
-				Device:            dev,
+				Name:       s.Name(),
+				UniqueID:   fmt.Sprintf("%s_%s", a.deviceId, key),
+				StateTopic: stateTopic,
+				Availability: []haAvailability{
+					{Topic: a.availabilityTopic},
+					{Topic: fmt.Sprintf("%s/%s/availability", a.stateBase, key)},
+				},
+				AvailabilityMode: "all",
+				DisplayPrecision: s.Precision(),
+				Device:           dev,
+			}
+
+			if _, ok := s.(sensors.AttributesSensor); ok {
+				payload.AttributesTopic = fmt.Sprintf("%s/%s/attributes", a.stateBase, key)
 			}
 
 			if ha := s.HA(); ha != nil {
@@ -74,6 +96,16 @@ func (a *agent) publishDiscovery() error {
 				if ha.StateClass != "" {
 					payload.StateClass = ha.StateClass
 				}
+				if ha.ExpireAfter > 0 {
+					payload.ExpireAfter = int(ha.ExpireAfter / time.Second)
+				}
+			}
+
+			// Binary sensors have ON/OFF states without unit or statistics.
+			if component == "binary_sensor" {
+				payload.Unit = ""
+				payload.StateClass = ""
+				payload.DisplayPrecision = nil
 			}
 
 			b, err := json.Marshal(payload)
@@ -119,19 +151,39 @@ func (a *agent) publishDiscovery() error {
 	return nil
 }
 
-func (a *agent) collectAndPublishGroup(ctx context.Context, group []sensors.Sensor, sensorsStateCache map[string]string) {
+// publishedState is the last payload published to a sensor topic.
+type publishedState struct {
+	payload string
+	reading sensors.Reading
+	at      time.Time
+}
+
+func (a *agent) collectAndPublishGroup(ctx context.Context, group []sensors.Sensor, sensorsStateCache map[string]publishedState) {
 	for _, s := range group {
 		topic := fmt.Sprintf("%s/%s/state", a.stateBase, s.Key())
 
-		val, err := s.Collect(ctx)
+		reading, attrs, err := sensors.Collect(ctx, s)
 		if err != nil {
 			slog.Error("collect failed", "sensor", s.Key(), "err", err)
 		}
 
-		if prev, ok := sensorsStateCache[s.Key()]; ok && prev == val {
+		if attrs != nil {
+			a.publishAttributes(s, attrs, sensorsStateCache)
+		}
+
+		a.publishSensorAvailability(s, reading.Available(), sensorsStateCache)
+		if !reading.Available() {
+			// Keep the last retained state; the entity shows as unavailable
+			// until the sensor produces a value again.
 			continue
 		}
-		sensorsStateCache[s.Key()] = val
+
+		val := reading.Format(s.Precision())
+
+		if prev, ok := sensorsStateCache[s.Key()]; ok && !shouldPublish(s.PublishPolicy(), s.Interval(), prev, reading, val) {
+			continue
+		}
+		sensorsStateCache[s.Key()] = publishedState{payload: val, reading: reading, at: time.Now()}
 
 		if err := a.pub.Publish(topic, 1, true, []byte(val)); err != nil {
 			slog.Error("publish failed", "sensor", s.Key(), "topic", topic, "err", err)
@@ -140,3 +192,72 @@ func (a *agent) collectAndPublishGroup(ctx context.Context, group []sensors.Sens
 		}
 	}
 }
+
+// shouldPublish reports whether a state differs enough from the last
+// published one, or is due for a heartbeat republish.
+func shouldPublish(policy sensors.PublishPolicy, interval time.Duration, prev publishedState, cur sensors.Reading, val string) bool {
+	// Collections happen on ticks, so allow half an interval of jitter
+	// to hit the tick closest to the heartbeat.
+	if policy.ForceUpdateEvery > 0 && time.Since(prev.at)+interval/2 >= policy.ForceUpdateEvery {
+		return true
+	}
+	if prev.payload == val {
+		return false
+	}
+
+	if cur.Kind == sensors.ReadingNumber && prev.reading.Kind == sensors.ReadingNumber {
+		delta := math.Abs(cur.Value - prev.reading.Value)
+		if delta < policy.MinChange {
+			return false
+		}
+		if delta < math.Abs(prev.reading.Value)*policy.MinChangePercent/100 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *agent) publishSensorAvailability(s sensors.Sensor, available bool, sensorsStateCache map[string]publishedState) {
+	topic := fmt.Sprintf("%s/%s/availability", a.stateBase, s.Key())
+
+	payload := "offline"
+	if available {
+		payload = "online"
+	}
+
+	cacheKey := s.Key() + "/availability"
+	if prev, ok := sensorsStateCache[cacheKey]; ok && prev.payload == payload {
+		return
+	}
+	sensorsStateCache[cacheKey] = publishedState{payload: payload, at: time.Now()}
+
+	if err := a.pub.Publish(topic, 1, true, []byte(payload)); err != nil {
+		slog.Error("publish failed", "sensor", s.Key(), "topic", topic, "err", err)
+	} else {
+		slog.Debug("published", "sensor", s.Key(), "topic", topic, "value", payload)
+	}
+}
+
+func (a *agent) publishAttributes(s sensors.Sensor, attrs map[string]any, sensorsStateCache map[string]publishedState) {
+	topic := fmt.Sprintf("%s/%s/attributes", a.stateBase, s.Key())
+
+	b, err := json.Marshal(attrs)
+	if err != nil {
+		slog.Error("attributes marshal failed", "sensor", s.Key(), "err", err)
+		return
+	}
+
+	// Attributes share the dedup cache with states under a distinct key.
+	cacheKey := s.Key() + "/attributes"
+	if prev, ok := sensorsStateCache[cacheKey]; ok && prev.payload == string(b) {
+		return
+	}
+	sensorsStateCache[cacheKey] = publishedState{payload: string(b), at: time.Now()}
+
+	if err := a.pub.Publish(topic, 1, true, b); err != nil {
+		slog.Error("publish failed", "sensor", s.Key(), "topic", topic, "err", err)
+	} else {
+		slog.Debug("published", "sensor", s.Key(), "topic", topic, "value", string(b))
+	}
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/sensors"
+)
+
+func TestShouldPublish(t *testing.T) {
+	num := func(v float64) sensors.Reading {
+		return sensors.Reading{Kind: sensors.ReadingNumber, Value: v, Precision: 1}
+	}
+	txt := func(s string) sensors.Reading {
+		return sensors.Reading{Kind: sensors.ReadingText, Text: s}
+	}
+
+	tests := []struct {
+		name   string
+		policy sensors.PublishPolicy
+		prev   sensors.Reading
+		prevAt time.Duration // age of the last publish
+		cur    sensors.Reading
+		want   bool
+	}{
+		{name: "unchanged", prev: num(20), cur: num(20), want: false},
+		{name: "changed", prev: num(20), cur: num(20.1), want: true},
+		{name: "text changed", prev: txt("ONLINE"), cur: txt("DEGRADED"), want: true},
+
+		{name: "below min change", policy: sensors.PublishPolicy{MinChange: 0.5}, prev: num(20), cur: num(20.4), want: false},
+		{name: "at min change", policy: sensors.PublishPolicy{MinChange: 0.5}, prev: num(20), cur: num(19.5), want: true},
+		{name: "below min change percent", policy: sensors.PublishPolicy{MinChangePercent: 5}, prev: num(200), cur: num(209), want: false},
+		{name: "at min change percent", policy: sensors.PublishPolicy{MinChangePercent: 5}, prev: num(200), cur: num(190), want: true},
+		{name: "percent of negative value", policy: sensors.PublishPolicy{MinChangePercent: 10}, prev: num(-50), cur: num(-46), want: false},
+		{name: "percent from zero", policy: sensors.PublishPolicy{MinChangePercent: 10}, prev: num(0), cur: num(0.1), want: true},
+		{name: "both thresholds apply", policy: sensors.PublishPolicy{MinChange: 1, MinChangePercent: 1}, prev: num(500), cur: num(504), want: false},
+		{name: "deadband ignores text", policy: sensors.PublishPolicy{MinChange: 100}, prev: txt("a"), cur: txt("b"), want: true},
+		{name: "deadband ignores kind change", policy: sensors.PublishPolicy{MinChange: 100}, prev: txt("n/a"), cur: num(1), want: true},
+
+		{name: "heartbeat due", policy: sensors.PublishPolicy{ForceUpdateEvery: 5 * time.Minute}, prev: num(20), prevAt: 5 * time.Minute, cur: num(20), want: true},
+		// A minute interval: the tick 30s before the heartbeat is the closest one.
+		{name: "heartbeat within jitter", policy: sensors.PublishPolicy{ForceUpdateEvery: 5 * time.Minute}, prev: num(20), prevAt: 4*time.Minute + 31*time.Second, cur: num(20), want: true},
+		{name: "heartbeat not due", policy: sensors.PublishPolicy{ForceUpdateEvery: 5 * time.Minute}, prev: num(20), prevAt: 4 * time.Minute, cur: num(20), want: false},
+		{name: "heartbeat overrides deadband", policy: sensors.PublishPolicy{MinChange: 1, ForceUpdateEvery: 5 * time.Minute}, prev: num(20), prevAt: 6 * time.Minute, cur: num(20.2), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := publishedState{
+				payload: tt.prev.Format(nil),
+				reading: tt.prev,
+				at:      time.Now().Add(-tt.prevAt),
+			}
+			got := shouldPublish(tt.policy, time.Minute, prev, tt.cur, tt.cur.Format(nil))
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
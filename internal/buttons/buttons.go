@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"sort"
 	"time"
 
 	"github.com/Miklakapi/gometrum/internal/config"
@@ -12,7 +13,9 @@ import (
 type Button interface {
 	Key() string
 	Name() string
-	Command() []string
+	// LogAttrs describes what a press does as structured log fields,
+	// e.g. the executed command.
+	LogAttrs() []any
 	Timeout() time.Duration
 	Icon() string
 
@@ -29,7 +32,7 @@ type base struct {
 
 func (b base) Key() string            { return b.key }
 func (b base) Name() string           { return b.name }
-func (b base) Command() []string      { return b.command }
+func (b base) LogAttrs() []any        { return []any{"cmd", b.command} }
 func (b base) Timeout() time.Duration { return b.timeout }
 func (b base) Icon() string           { return b.icon }
 
@@ -39,8 +42,37 @@ type commandButton struct {
 
 func Build(cfg config.Config) ([]Button, error) {
 	out := make([]Button, 0, len(cfg.Buttons))
-	for key, bc := range cfg.Buttons {
-		out = append(out, newCommandButton(key, bc))
+
+	keys := make([]string, 0, len(cfg.Buttons))
+	for key := range cfg.Buttons {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Generated keys become MQTT topics and Home Assistant unique IDs.
+	owners := make(map[string]string, len(cfg.Buttons))
+
+	for _, key := range keys {
+		bc := cfg.Buttons[key]
+
+		var list []Button
+		switch bc.Type {
+		case "systemd":
+			list = newSystemdButtons(key, bc)
+		case "container":
+			list = newContainerButtons(key, bc)
+		default:
+			list = []Button{newCommandButton(key, bc)}
+		}
+
+		for _, b := range list {
+			if owner, ok := owners[b.Key()]; ok {
+				return nil, fmt.Errorf("buttons: %s generates key %s already used by buttons.%s", key, b.Key(), owner)
+			}
+			owners[b.Key()] = key
+		}
+
+		out = append(out, list...)
 	}
 	return out, nil
 }
@@ -0,0 +1,62 @@
+package buttons
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/container"
+	"github.com/Miklakapi/gometrum/internal/keys"
+)
+
+// containerButton starts, stops or restarts a container through
+// the Docker or Podman API.
+type containerButton struct {
+	base
+	client    *container.Client
+	container string
+	action    string
+}
+
+// newContainerButtons creates one button per container, keyed like fan-out sensors.
+func newContainerButtons(key string, cfg config.ButtonConfig) []Button {
+	client := container.NewClient(cfg.Socket, cfg.Timeout)
+
+	names := append([]string(nil), cfg.Containers...)
+	sort.Strings(names)
+
+	out := make([]Button, 0, len(names))
+	for _, n := range names {
+		out = append(out, &containerButton{
+			base: base{
+				key:     key + "_" + keys.Sanitize(n),
+				name:    fmt.Sprintf("%s %s", cfg.Name, n),
+				timeout: cfg.Timeout,
+				icon:    cfg.HA.Icon,
+			},
+			client:    client,
+			container: n,
+			action:    cfg.Action,
+		})
+	}
+	return out
+}
+
+func (b *containerButton) LogAttrs() []any {
+	return []any{"container", b.container, "action", b.action}
+}
+
+func (b *containerButton) Execute(parent context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(parent, b.timeout)
+	defer cancel()
+
+	err := b.client.Do(ctx, b.container, b.action)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timeout exceeded (%s)", b.timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(b.action + " " + b.container + " done"), nil
+}
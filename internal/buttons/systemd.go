@@ -0,0 +1,81 @@
+package buttons
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/keys"
+	"github.com/Miklakapi/gometrum/internal/systemd"
+)
+
+// systemdConnect opens the connection used for a single button press.
+var systemdConnect = systemd.Connect
+
+// systemdButton starts, stops or restarts a unit through the systemd D-Bus API.
+type systemdButton struct {
+	base
+	unit   string
+	action string
+}
+
+// newSystemdButtons creates one button per unit, keyed like fan-out sensors.
+func newSystemdButtons(key string, cfg config.ButtonConfig) []Button {
+	units := append([]string(nil), cfg.Units...)
+	sort.Strings(units)
+
+	out := make([]Button, 0, len(units))
+	for _, u := range units {
+		unit := systemd.UnitName(u)
+		short := strings.TrimSuffix(unit, ".service")
+
+		out = append(out, &systemdButton{
+			base: base{
+				key:     key + "_" + keys.Sanitize(short),
+				name:    fmt.Sprintf("%s %s", cfg.Name, short),
+				timeout: cfg.Timeout,
+				icon:    cfg.HA.Icon,
+			},
+			unit:   unit,
+			action: cfg.Action,
+		})
+	}
+	return out
+}
+
+func (b *systemdButton) LogAttrs() []any {
+	return []any{"unit", b.unit, "action", b.action}
+}
+
+func (b *systemdButton) Execute(parent context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(parent, b.timeout)
+	defer cancel()
+
+	m, err := systemdConnect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	var job string
+	switch b.action {
+	case "start":
+		job, err = m.StartUnit(ctx, b.unit)
+	case "stop":
+		job, err = m.StopUnit(ctx, b.unit)
+	case "restart":
+		job, err = m.RestartUnit(ctx, b.unit)
+	default:
+		return nil, fmt.Errorf("unknown action %q", b.action)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timeout exceeded (%s)", b.timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte("queued job " + job), nil
+}
@@ -0,0 +1,84 @@
+package buttons
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/systemd"
+)
+
+// fakeSystemd records the jobs queued by buttons.
+type fakeSystemd struct {
+	jobs   []string
+	closed bool
+}
+
+func (f *fakeSystemd) UnitStatus(context.Context, string) (systemd.UnitStatus, error) {
+	return systemd.UnitStatus{}, errors.New("not used by buttons")
+}
+
+func (f *fakeSystemd) StartUnit(_ context.Context, unit string) (string, error) {
+	return f.queue("start", unit)
+}
+
+func (f *fakeSystemd) StopUnit(_ context.Context, unit string) (string, error) {
+	return f.queue("stop", unit)
+}
+
+func (f *fakeSystemd) RestartUnit(_ context.Context, unit string) (string, error) {
+	return f.queue("restart", unit)
+}
+
+func (f *fakeSystemd) queue(action, unit string) (string, error) {
+	f.jobs = append(f.jobs, action+" "+unit)
+	return "/org/freedesktop/systemd1/job/1", nil
+}
+
+func (f *fakeSystemd) Connected() bool { return !f.closed }
+func (f *fakeSystemd) Close() error    { f.closed = true; return nil }
+
+func TestSystemdButtons(t *testing.T) {
+	fake := &fakeSystemd{}
+	prev := systemdConnect
+	systemdConnect = func() (systemd.Manager, error) { return fake, nil }
+	t.Cleanup(func() { systemdConnect = prev })
+
+	list := newSystemdButtons("restart", config.ButtonConfig{
+		Name:    "Restart",
+		Action:  "restart",
+		Units:   []string{"nginx", "backup.timer"},
+		Timeout: time.Second,
+		HA:      &config.HAButtonConfig{},
+	})
+
+	var keys []string
+	for _, b := range list {
+		keys = append(keys, b.Key())
+	}
+	if want := []string{"restart_backup_timer", "restart_nginx"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got keys %v, want %v", keys, want)
+	}
+
+	b := list[1]
+	if want := []any{"unit", "nginx.service", "action", "restart"}; !reflect.DeepEqual(b.LogAttrs(), want) {
+		t.Errorf("got log attrs %v, want %v", b.LogAttrs(), want)
+	}
+
+	out, err := b.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "queued job /org/freedesktop/systemd1/job/1" {
+		t.Errorf("got output %q", out)
+	}
+	if want := []string{"restart nginx.service"}; !reflect.DeepEqual(fake.jobs, want) {
+		t.Errorf("got jobs %v, want %v", fake.jobs, want)
+	}
+	if !fake.closed {
+		t.Error("the connection of the press was not closed")
+	}
+}
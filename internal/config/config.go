@@ -134,12 +134,88 @@ func normalizeConfig(cfg *Config) {
 			continue
 		}
 
+		sensor.Type = strings.ToLower(strings.TrimSpace(sensor.Type))
 		sensor.Name = strings.TrimSpace(sensor.Name)
+		sensor.Backend = strings.ToLower(strings.TrimSpace(sensor.Backend))
+		sensor.Chip = strings.TrimSpace(sensor.Chip)
+		sensor.Label = strings.TrimSpace(sensor.Label)
+		sensor.Aggregate = strings.ToLower(strings.TrimSpace(sensor.Aggregate))
+		sensor.Family = strings.ToLower(strings.TrimSpace(sensor.Family))
+		sensor.Interface = strings.TrimSpace(sensor.Interface)
+		sensor.JSONPath = strings.TrimSpace(sensor.JSONPath)
+		sensor.Path = strings.TrimSpace(sensor.Path)
+		sensor.URL = strings.TrimSpace(sensor.URL)
+		sensor.Metric = strings.TrimSpace(sensor.Metric)
+		sensor.ProcessName = strings.TrimSpace(sensor.ProcessName)
+		sensor.PIDFile = strings.TrimSpace(sensor.PIDFile)
+		sensor.Cgroup = strings.TrimSpace(sensor.Cgroup)
+		sensor.Socket = strings.TrimSpace(sensor.Socket)
+
+		if sensor.Headers != nil {
+			normalized := make(map[string]string, len(sensor.Headers))
+			for k, v := range sensor.Headers {
+				key := strings.TrimSpace(k)
+				if key == "" {
+					continue
+				}
+				normalized[key] = strings.TrimSpace(v)
+			}
+			sensor.Headers = normalized
+		}
+
+		if sensor.Values != nil {
+			normalized := make(map[string]SensorValueConfig, len(sensor.Values))
+			for k, v := range sensor.Values {
+				v.Name = strings.TrimSpace(v.Name)
+				v.JSONPath = strings.TrimSpace(v.JSONPath)
+				v.Metric = strings.TrimSpace(v.Metric)
+				if v.HA != nil {
+					v.HA.Icon = strings.TrimSpace(v.HA.Icon)
+					v.HA.Unit = strings.TrimSpace(v.HA.Unit)
+					v.HA.DeviceClass = strings.TrimSpace(v.HA.DeviceClass)
+					v.HA.StateClass = strings.TrimSpace(v.HA.StateClass)
+				}
+				normalized[strings.TrimSpace(k)] = v
+			}
+			sensor.Values = normalized
+		}
+
+		for i, arg := range sensor.Command {
+			sensor.Command[i] = strings.TrimSpace(arg)
+		}
+
+		for i, t := range sensor.Transforms {
+			sensor.Transforms[i].Convert = strings.TrimSpace(t.Convert)
+		}
 
 		for i, m := range sensor.IncludeMounts {
 			sensor.IncludeMounts[i] = strings.TrimSpace(m)
 		}
 
+		for i, g := range sensor.GPUs {
+			sensor.GPUs[i] = strings.TrimSpace(g)
+		}
+
+		for i, d := range sensor.IncludeDevices {
+			sensor.IncludeDevices[i] = strings.TrimPrefix(strings.TrimSpace(d), "/dev/")
+		}
+
+		for i, u := range sensor.Units {
+			sensor.Units[i] = strings.TrimSpace(u)
+		}
+
+		for i, c := range sensor.Containers {
+			sensor.Containers[i] = strings.TrimSpace(c)
+		}
+
+		for i, iface := range sensor.IncludeInterfaces {
+			sensor.IncludeInterfaces[i] = strings.TrimSpace(iface)
+		}
+
+		for i, m := range sensor.Metrics {
+			sensor.Metrics[i] = strings.ToLower(strings.TrimSpace(m))
+		}
+
 		if sensor.HA != nil {
 			sensor.HA.Icon = strings.TrimSpace(sensor.HA.Icon)
 			sensor.HA.Unit = strings.TrimSpace(sensor.HA.Unit)
@@ -158,12 +234,24 @@ func normalizeConfig(cfg *Config) {
 			continue
 		}
 
+		button.Type = strings.ToLower(strings.TrimSpace(button.Type))
 		button.Name = strings.TrimSpace(button.Name)
+		button.Action = strings.ToLower(strings.TrimSpace(button.Action))
 
 		for i, arg := range button.Command {
 			button.Command[i] = strings.TrimSpace(arg)
 		}
 
+		for i, u := range button.Units {
+			button.Units[i] = strings.TrimSpace(u)
+		}
+
+		for i, c := range button.Containers {
+			button.Containers[i] = strings.TrimSpace(c)
+		}
+
+		button.Socket = strings.TrimSpace(button.Socket)
+
 		if button.HA != nil {
 			button.HA.Icon = strings.TrimSpace(button.HA.Icon)
 		}
@@ -246,6 +334,10 @@ func applyDefaults(cfg *Config) {
 	}
 
 	for key, buttonCfg := range cfg.Buttons {
+		if buttonCfg.Type == "" {
+			buttonCfg.Type = "command"
+		}
+
 		if buttonCfg.Timeout <= 0 {
 			buttonCfg.Timeout = 10 * time.Second
 		}
@@ -254,7 +346,16 @@ func applyDefaults(cfg *Config) {
 			buttonCfg.HA = &HAButtonConfig{}
 		}
 		if buttonCfg.HA.Icon == "" {
-			buttonCfg.HA.Icon = "mdi:gesture-tap-button"
+			switch buttonCfg.Action {
+			case "start":
+				buttonCfg.HA.Icon = "mdi:play"
+			case "stop":
+				buttonCfg.HA.Icon = "mdi:stop"
+			case "restart":
+				buttonCfg.HA.Icon = "mdi:restart"
+			default:
+				buttonCfg.HA.Icon = "mdi:gesture-tap-button"
+			}
 		}
 
 		cfg.Buttons[key] = buttonCfg
@@ -58,24 +58,95 @@ type AgentConfig struct {
 }
 
 type SensorConfig struct {
-	Name          string          `yaml:"name"`
-	Interval      time.Duration   `yaml:"interval"`
-	IncludeMounts []string        `yaml:"include_mounts,omitempty"`
-	HA            *HASensorConfig `yaml:"ha,omitempty"`
+	Type              string                       `yaml:"type,omitempty"`
+	Name              string                       `yaml:"name"`
+	Interval          time.Duration                `yaml:"interval"`
+	Precision         *int                         `yaml:"precision,omitempty"`
+	Transforms        []TransformConfig            `yaml:"transforms,omitempty"`
+	MinChange         float64                      `yaml:"min_change,omitempty"`
+	MinChangePercent  float64                      `yaml:"min_change_percent,omitempty"`
+	ForceUpdateEvery  time.Duration                `yaml:"force_update_every,omitempty"`
+	IncludeMounts     []string                     `yaml:"include_mounts,omitempty"`
+	IncludeCores      []int                        `yaml:"include_cores,omitempty"`
+	IncludeDevices    []string                     `yaml:"include_devices,omitempty"`
+	IncludeInterfaces []string                     `yaml:"include_interfaces,omitempty"`
+	Interface         string                       `yaml:"interface,omitempty"`
+	Metrics           []string                     `yaml:"metrics,omitempty"`
+	GPUs              []string                     `yaml:"gpus,omitempty"`
+	Backend           string                       `yaml:"backend,omitempty"`
+	Chip              string                       `yaml:"chip,omitempty"`
+	Label             string                       `yaml:"label,omitempty"`
+	Aggregate         string                       `yaml:"aggregate,omitempty"`
+	Family            string                       `yaml:"family,omitempty"`
+	ExcludeLinkLocal  bool                         `yaml:"exclude_link_local,omitempty"`
+	ExcludeVirtual    bool                         `yaml:"exclude_virtual,omitempty"`
+	Attributes        bool                         `yaml:"attributes,omitempty"`
+	Command           []string                     `yaml:"command,omitempty"`
+	Timeout           time.Duration                `yaml:"timeout,omitempty"`
+	Regex             string                       `yaml:"regex,omitempty"`
+	JSONPath          string                       `yaml:"json_path,omitempty"`
+	Field             int                          `yaml:"field,omitempty"`
+	Path              string                       `yaml:"path,omitempty"`
+	Scale             float64                      `yaml:"scale,omitempty"`
+	URL               string                       `yaml:"url,omitempty"`
+	Headers           map[string]string            `yaml:"headers,omitempty"`
+	AcceptStatus      []int                        `yaml:"accept_status,omitempty"`
+	Units             []string                     `yaml:"units,omitempty"`
+	ProcessName       string                       `yaml:"process_name,omitempty"`
+	Cmdline           string                       `yaml:"cmdline,omitempty"`
+	PIDFile           string                       `yaml:"pidfile,omitempty"`
+	Cgroup            string                       `yaml:"cgroup,omitempty"`
+	Containers        []string                     `yaml:"containers,omitempty"`
+	Socket            string                       `yaml:"socket,omitempty"`
+	Metric            string                       `yaml:"metric,omitempty"`
+	Rate              bool                         `yaml:"rate,omitempty"`
+	Values            map[string]SensorValueConfig `yaml:"values,omitempty"`
+	HA                *HASensorConfig              `yaml:"ha,omitempty"`
+}
+
+// SensorValueConfig selects one value of a response shared by several
+// entities. Empty HA fields fall back to the sensor's ha settings.
+type SensorValueConfig struct {
+	Name     string          `yaml:"name,omitempty"`
+	Regex    string          `yaml:"regex,omitempty"`
+	JSONPath string          `yaml:"json_path,omitempty"`
+	Field    int             `yaml:"field,omitempty"`
+	Metric   string          `yaml:"metric,omitempty"`
+	Rate     bool            `yaml:"rate,omitempty"`
+	HA       *HASensorConfig `yaml:"ha,omitempty"`
+}
+
+// TransformConfig is one step of a sensor's value pipeline.
+// Exactly one operation is set per step; min and max together form a clamp.
+type TransformConfig struct {
+	Scale   *float64 `yaml:"scale,omitempty"`
+	Offset  *float64 `yaml:"offset,omitempty"`
+	Convert string   `yaml:"convert,omitempty"`
+	Min     *float64 `yaml:"min,omitempty"`
+	Max     *float64 `yaml:"max,omitempty"`
+	Round   *int     `yaml:"round,omitempty"`
+	Average int      `yaml:"average,omitempty"`
+	Median  int      `yaml:"median,omitempty"`
 }
 
 type HASensorConfig struct {
-	Icon        string `yaml:"icon,omitempty"`
-	Unit        string `yaml:"unit,omitempty"`
-	DeviceClass string `yaml:"device_class,omitempty"`
-	StateClass  string `yaml:"state_class,omitempty"`
+	Icon        string        `yaml:"icon,omitempty"`
+	Unit        string        `yaml:"unit,omitempty"`
+	DeviceClass string        `yaml:"device_class,omitempty"`
+	StateClass  string        `yaml:"state_class,omitempty"`
+	ExpireAfter time.Duration `yaml:"expire_after,omitempty"`
 }
 
 type ButtonConfig struct {
-	Name    string          `yaml:"name"`
-	Command []string        `yaml:"command"`
-	Timeout time.Duration   `yaml:"timeout"`
-	HA      *HAButtonConfig `yaml:"ha,omitempty"`
+	Type       string          `yaml:"type,omitempty"`
+	Name       string          `yaml:"name"`
+	Command    []string        `yaml:"command,omitempty"`
+	Units      []string        `yaml:"units,omitempty"`
+	Containers []string        `yaml:"containers,omitempty"`
+	Socket     string          `yaml:"socket,omitempty"`
+	Action     string          `yaml:"action,omitempty"`
+	Timeout    time.Duration   `yaml:"timeout"`
+	HA         *HAButtonConfig `yaml:"ha,omitempty"`
 }
 
 type HAButtonConfig struct {
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net"
 	neturl "net/url"
+	"regexp"
+	"time"
 )
 
 func validateLogLevel(lc LogConfig) error {
@@ -149,6 +151,10 @@ func validateAgent(ac AgentConfig) error {
 	return nil
 }
 
+// sensorKeyPattern matches sensor keys, which become MQTT topic levels
+// and Home Assistant unique IDs.
+var sensorKeyPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 func validateSensors(sc map[string]SensorConfig) error {
 	if len(sc) == 0 {
 		return errors.New("config: sensors section must not be empty (define at least one sensor)")
@@ -158,9 +164,15 @@ func validateSensors(sc map[string]SensorConfig) error {
 		if sensorKey == "" {
 			return errors.New("config: sensors contains an empty key")
 		}
+		if !sensorKeyPattern.MatchString(sensorKey) {
+			return fmt.Errorf("config: sensors.%s: key must contain only lowercase letters, digits and underscores", sensorKey)
+		}
 		if sensorCfg.Interval <= 0 {
 			return errors.New("config: sensors." + sensorKey + ".interval resolved to 0 (check mqtt.default_interval)")
 		}
+		if p := sensorCfg.Precision; p != nil && (*p < 0 || *p > 10) {
+			return fmt.Errorf("config: sensors.%s.precision must be between 0 and 10 (got: %d)", sensorKey, *p)
+		}
 
 		if len(sensorCfg.IncludeMounts) > 0 {
 			seen := make(map[string]struct{}, len(sensorCfg.IncludeMounts))
@@ -175,11 +187,119 @@ func validateSensors(sc map[string]SensorConfig) error {
 				seen[m] = struct{}{}
 			}
 		}
+
+		if len(sensorCfg.IncludeCores) > 0 {
+			seen := make(map[int]struct{}, len(sensorCfg.IncludeCores))
+
+			for _, c := range sensorCfg.IncludeCores {
+				if c < 0 {
+					return fmt.Errorf("config: sensors.%s.include_cores contains a negative core: %d", sensorKey, c)
+				}
+				if _, ok := seen[c]; ok {
+					return fmt.Errorf("config: sensors.%s.include_cores contains duplicate core: %d", sensorKey, c)
+				}
+				seen[c] = struct{}{}
+			}
+		}
+
+		if err := validateStringList("sensors."+sensorKey+".gpus", "selector", sensorCfg.GPUs); err != nil {
+			return err
+		}
+		if err := validateStringList("sensors."+sensorKey+".include_devices", "device", sensorCfg.IncludeDevices); err != nil {
+			return err
+		}
+		if err := validateStringList("sensors."+sensorKey+".include_interfaces", "interface", sensorCfg.IncludeInterfaces); err != nil {
+			return err
+		}
+		if err := validateStringList("sensors."+sensorKey+".metrics", "metric", sensorCfg.Metrics); err != nil {
+			return err
+		}
+		if err := validateStringList("sensors."+sensorKey+".units", "unit", sensorCfg.Units); err != nil {
+			return err
+		}
+		if err := validateStringList("sensors."+sensorKey+".containers", "container", sensorCfg.Containers); err != nil {
+			return err
+		}
+
+		for i, arg := range sensorCfg.Command {
+			if arg == "" {
+				return fmt.Errorf("config: sensors.%s.command[%d] cannot be empty", sensorKey, i)
+			}
+		}
+		if sensorCfg.Timeout < 0 {
+			return fmt.Errorf("config: sensors.%s.timeout must not be negative", sensorKey)
+		}
+
+		if sensorCfg.Field < 0 {
+			return fmt.Errorf("config: sensors.%s.field must be a positive field number (got: %d)", sensorKey, sensorCfg.Field)
+		}
+
+		if sensorCfg.MinChange < 0 {
+			return fmt.Errorf("config: sensors.%s.min_change must not be negative (got: %g)", sensorKey, sensorCfg.MinChange)
+		}
+		if sensorCfg.MinChangePercent < 0 {
+			return fmt.Errorf("config: sensors.%s.min_change_percent must not be negative (got: %g)", sensorKey, sensorCfg.MinChangePercent)
+		}
+		if sensorCfg.ForceUpdateEvery < 0 {
+			return fmt.Errorf("config: sensors.%s.force_update_every must not be negative", sensorKey)
+		}
+
+		if err := validateExpireAfter("sensors."+sensorKey, sensorCfg.HA, sensorCfg.ForceUpdateEvery); err != nil {
+			return err
+		}
+
+		if sensorCfg.URL != "" {
+			if err := validateHTTPURL(sensorCfg.URL); err != nil {
+				return fmt.Errorf("config: sensors.%s.url is invalid: %w", sensorKey, err)
+			}
+		}
+		for _, code := range sensorCfg.AcceptStatus {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("config: sensors.%s.accept_status must contain HTTP status codes (got: %d)", sensorKey, code)
+			}
+		}
+
+		for name, v := range sensorCfg.Values {
+			path := "sensors." + sensorKey + ".values"
+			if name == "" {
+				return errors.New("config: " + path + " contains an empty key")
+			}
+			if v.Field < 0 {
+				return fmt.Errorf("config: %s.%s.field must be a positive field number (got: %d)", path, name, v.Field)
+			}
+			if err := validateExpireAfter(path+"."+name, v.HA, sensorCfg.ForceUpdateEvery); err != nil {
+				return err
+			}
+		}
+
+		for i, t := range sensorCfg.Transforms {
+			if err := validateTransform(t); err != nil {
+				return fmt.Errorf("config: sensors.%s.transforms[%d]: %w", sensorKey, i, err)
+			}
+		}
 	}
 
 	return nil
 }
 
+// validateExpireAfter checks ha.expire_after of an entity. Unchanged values
+// are not republished, so it needs a heartbeat shorter than the expiry.
+func validateExpireAfter(path string, ha *HASensorConfig, forceUpdateEvery time.Duration) error {
+	if ha == nil || ha.ExpireAfter == 0 {
+		return nil
+	}
+	if ha.ExpireAfter < time.Second {
+		return fmt.Errorf("config: %s.ha.expire_after must be at least 1s", path)
+	}
+	if forceUpdateEvery == 0 {
+		return fmt.Errorf("config: %s.ha.expire_after requires force_update_every", path)
+	}
+	if forceUpdateEvery >= ha.ExpireAfter {
+		return fmt.Errorf("config: %s.ha.expire_after must be longer than force_update_every", path)
+	}
+	return nil
+}
+
 func validateButtons(bc map[string]ButtonConfig) error {
 	if len(bc) == 0 {
 		return nil
@@ -194,14 +314,47 @@ func validateButtons(bc map[string]ButtonConfig) error {
 			return errors.New("config: buttons." + buttonKey + ".name is required")
 		}
 
-		if len(buttonCfg.Command) == 0 {
-			return errors.New("config: buttons." + buttonKey + ".command must contain at least one item (executable name)")
-		}
+		switch buttonCfg.Type {
+		case "command":
+			if len(buttonCfg.Command) == 0 {
+				return errors.New("config: buttons." + buttonKey + ".command must contain at least one item (executable name)")
+			}
 
-		for i, arg := range buttonCfg.Command {
-			if arg == "" {
-				return fmt.Errorf("config: buttons.%s.command[%d] cannot be empty", buttonKey, i)
+			for i, arg := range buttonCfg.Command {
+				if arg == "" {
+					return fmt.Errorf("config: buttons.%s.command[%d] cannot be empty", buttonKey, i)
+				}
+			}
+
+			if len(buttonCfg.Units) > 0 || len(buttonCfg.Containers) > 0 || buttonCfg.Action != "" {
+				return errors.New("config: buttons." + buttonKey + " contains systemd or container fields but type=command")
+			}
+
+		case "systemd":
+			if err := validateStringList("buttons."+buttonKey+".units", "unit", buttonCfg.Units); err != nil {
+				return err
+			}
+			if len(buttonCfg.Units) == 0 {
+				return errors.New("config: buttons." + buttonKey + ".units must contain at least one unit for type=systemd")
+			}
+
+			if err := validateButtonAction(buttonKey, buttonCfg); err != nil {
+				return err
+			}
+
+		case "container":
+			if err := validateStringList("buttons."+buttonKey+".containers", "container", buttonCfg.Containers); err != nil {
+				return err
 			}
+			if len(buttonCfg.Containers) == 0 {
+				return errors.New("config: buttons." + buttonKey + ".containers must contain at least one container for type=container")
+			}
+			if err := validateButtonAction(buttonKey, buttonCfg); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("config: buttons.%s.type must be one of: command, systemd, container (got: %s)", buttonKey, buttonCfg.Type)
 		}
 
 		if buttonCfg.Timeout <= 0 {
@@ -212,6 +365,78 @@ func validateButtons(bc map[string]ButtonConfig) error {
 	return nil
 }
 
+// validateButtonAction checks the fields of buttons controlling
+// systemd units or containers instead of running a command.
+func validateButtonAction(buttonKey string, buttonCfg ButtonConfig) error {
+	switch buttonCfg.Action {
+	case "start", "stop", "restart":
+	default:
+		return fmt.Errorf("config: buttons.%s.action must be one of: start, stop, restart (got: %s)", buttonKey, buttonCfg.Action)
+	}
+
+	if len(buttonCfg.Command) > 0 {
+		return fmt.Errorf("config: buttons.%s.command is not supported for type=%s", buttonKey, buttonCfg.Type)
+	}
+	if buttonCfg.Type == "systemd" && (len(buttonCfg.Containers) > 0 || buttonCfg.Socket != "") {
+		return errors.New("config: buttons." + buttonKey + " contains container fields but type=systemd")
+	}
+	if buttonCfg.Type == "container" && len(buttonCfg.Units) > 0 {
+		return errors.New("config: buttons." + buttonKey + ".units is not supported for type=container")
+	}
+	return nil
+}
+
+func validateTransform(t TransformConfig) error {
+	ops := 0
+	for _, set := range []bool{
+		t.Scale != nil,
+		t.Offset != nil,
+		t.Convert != "",
+		t.Min != nil || t.Max != nil,
+		t.Round != nil,
+		t.Average != 0,
+		t.Median != 0,
+	} {
+		if set {
+			ops++
+		}
+	}
+	if ops != 1 {
+		return errors.New("each step must set exactly one of: scale, offset, convert, min/max, round, average, median")
+	}
+
+	if t.Min != nil && t.Max != nil && *t.Min > *t.Max {
+		return fmt.Errorf("min (%g) must not be greater than max (%g)", *t.Min, *t.Max)
+	}
+	if t.Round != nil && (*t.Round < 0 || *t.Round > 10) {
+		return fmt.Errorf("round must be between 0 and 10 (got: %d)", *t.Round)
+	}
+	if t.Average < 0 {
+		return fmt.Errorf("average must be a positive number of samples (got: %d)", t.Average)
+	}
+	if t.Median < 0 {
+		return fmt.Errorf("median must be a positive number of samples (got: %d)", t.Median)
+	}
+
+	return nil
+}
+
+func validateStringList(path, item string, list []string) error {
+	seen := make(map[string]struct{}, len(list))
+
+	for _, v := range list {
+		if v == "" {
+			return fmt.Errorf("config: %s contains an empty %s", path, item)
+		}
+		if _, ok := seen[v]; ok {
+			return fmt.Errorf("config: %s contains duplicate %s: %s", path, item, v)
+		}
+		seen[v] = struct{}{}
+	}
+
+	return nil
+}
+
 func isValidLogLevel(level string) bool {
 	switch level {
 	case "debug", "info", "warn", "error":
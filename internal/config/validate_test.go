@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateSensorKeys(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: "cpu_usage"},
+		{key: "disk2"},
+		{key: "Cpu", wantErr: true},
+		{key: "cpu-usage", wantErr: true},
+		{key: "cpu usage", wantErr: true},
+		{key: "cpu/usage", wantErr: true},
+		{key: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := validateSensors(map[string]SensorConfig{tt.key: {Interval: time.Minute}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAcceptStatus(t *testing.T) {
+	tests := []struct {
+		codes   []int
+		wantErr bool
+	}{
+		{codes: []int{200, 503}},
+		{codes: []int{99}, wantErr: true},
+		{codes: []int{600}, wantErr: true},
+	}
+	for _, tt := range tests {
+		sensors := map[string]SensorConfig{"app": {Interval: time.Minute, AcceptStatus: tt.codes}}
+		if err := validateSensors(sensors); (err != nil) != tt.wantErr {
+			t.Errorf("accept_status %v: got %v, want error %v", tt.codes, err, tt.wantErr)
+		}
+	}
+}
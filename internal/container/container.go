@@ -0,0 +1,170 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Sockets probed when no socket is configured, in order: Docker, then rootful Podman.
+var defaultSockets = []string{"/var/run/docker.sock", "/run/podman/podman.sock"}
+
+var ErrNotFound = errors.New("container not found")
+
+// Client talks to the Docker Engine API, also served by Podman,
+// over a local UNIX socket.
+type Client struct {
+	http   *http.Client
+	socket string
+}
+
+// NewClient returns a client for socket, or for the first existing
+// default socket when socket is empty.
+func NewClient(socket string, timeout time.Duration) *Client {
+	if socket == "" {
+		socket = defaultSockets[0]
+		for _, s := range defaultSockets {
+			if _, err := os.Stat(s); err == nil {
+				socket = s
+				break
+			}
+		}
+	}
+
+	tr := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+
+	return &Client{
+		http:   &http.Client{Transport: tr, Timeout: timeout},
+		socket: socket,
+	}
+}
+
+func (c *Client) Socket() string { return c.socket }
+
+// Info is the subset of container inspect data used by GoMetrum.
+type Info struct {
+	Name         string `json:"Name"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		Status  string `json:"Status"`
+		Running bool   `json:"Running"`
+		Health  *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+}
+
+// Stats is the subset of container resource usage used by GoMetrum.
+type Stats struct {
+	CPUStats struct {
+		CPUUsage struct {
+			// TotalUsage is the CPU time used so far, in nanoseconds.
+			TotalUsage uint64 `json:"total_usage"`
+		} `json:"cpu_usage"`
+	} `json:"cpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
+
+// MemoryUsed returns memory usage without the inactive page cache,
+// as reported by `docker stats`.
+func (s Stats) MemoryUsed() uint64 {
+	used := s.MemoryStats.Usage
+	for _, k := range []string{"inactive_file", "total_inactive_file"} {
+		if v, ok := s.MemoryStats.Stats[k]; ok && v < used {
+			return used - v
+		}
+	}
+	return used
+}
+
+func (c *Client) Inspect(ctx context.Context, name string) (Info, error) {
+	var out Info
+	err := c.get(ctx, "/containers/"+url.PathEscape(name)+"/json", &out)
+	return out, err
+}
+
+// Stats returns a single stats sample without waiting for a second one.
+func (c *Client) Stats(ctx context.Context, name string) (Stats, error) {
+	var out Stats
+	err := c.get(ctx, "/containers/"+url.PathEscape(name)+"/stats?stream=false&one-shot=true", &out)
+	return out, err
+}
+
+// Do runs action (start, stop or restart) on a container.
+func (c *Client) Do(ctx context.Context, name, action string) error {
+	switch action {
+	case "start", "stop", "restart":
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://engine/containers/"+url.PathEscape(name)+"/"+action, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 304 means the container is already started or stopped.
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	return responseError(resp)
+}
+
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://engine"+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response failed: %w", err)
+	}
+	return nil
+}
+
+// responseError turns an error response into an error with the engine's message.
+func responseError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		return fmt.Errorf("engine: %s (status %d)", body.Message, resp.StatusCode)
+	}
+	return fmt.Errorf("engine: unexpected status %s", resp.Status)
+}
@@ -0,0 +1,120 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// serveEngine serves handler on a UNIX socket in a temporary directory
+// and returns the socket path.
+func serveEngine(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	socket := filepath.Join(t.TempDir(), "engine.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return socket
+}
+
+func TestClient(t *testing.T) {
+	var actions []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/web/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Name":"/web","RestartCount":3,"State":{"Status":"running","Running":true,"Health":{"Status":"healthy"}}}`))
+	})
+	mux.HandleFunc("GET /containers/web/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cpu_stats":{"cpu_usage":{"total_usage":5000}},"memory_stats":{"usage":1000,"stats":{"inactive_file":300}}}`))
+	})
+	mux.HandleFunc("GET /containers/missing/json", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"No such container: missing"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /containers/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("name") {
+		case "web":
+			actions = append(actions, r.PathValue("action"))
+			w.WriteHeader(http.StatusNoContent)
+		case "stopped":
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"container is paused"}`))
+		}
+	})
+
+	c := NewClient(serveEngine(t, mux), time.Second)
+	ctx := context.Background()
+
+	info, err := c.Inspect(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.State.Running || info.State.Status != "running" || info.RestartCount != 3 ||
+		info.State.Health == nil || info.State.Health.Status != "healthy" {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	if _, err := c.Inspect(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+
+	st, err := c.Stats(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.CPUStats.CPUUsage.TotalUsage != 5000 || st.MemoryUsed() != 700 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+
+	if err := c.Do(ctx, "web", "restart"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actions, []string{"restart"}) {
+		t.Errorf("got actions %v", actions)
+	}
+	if err := c.Do(ctx, "stopped", "stop"); err != nil {
+		t.Errorf("already stopped container: %v", err)
+	}
+	if err := c.Do(ctx, "paused", "stop"); err == nil || err.Error() != "engine: container is paused (status 409)" {
+		t.Errorf("got error %v", err)
+	}
+	if err := c.Do(ctx, "web", "kill"); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+}
+
+func TestMemoryUsed(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage uint64
+		stats map[string]uint64
+		want  uint64
+	}{
+		{name: "cgroup v2", usage: 1000, stats: map[string]uint64{"inactive_file": 400}, want: 600},
+		{name: "cgroup v1", usage: 1000, stats: map[string]uint64{"total_inactive_file": 100}, want: 900},
+		{name: "no page cache stats", usage: 1000, want: 1000},
+		{name: "cache above usage", usage: 1000, stats: map[string]uint64{"inactive_file": 2000}, want: 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Stats
+			s.MemoryStats.Usage, s.MemoryStats.Stats = tt.usage, tt.stats
+			if got := s.MemoryUsed(); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
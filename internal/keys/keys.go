@@ -0,0 +1,27 @@
+// Package keys builds the keys of generated entities. Keys become MQTT
+// topic levels and Home Assistant unique IDs, so sensors and buttons
+// derive them the same way.
+package keys
+
+import "strings"
+
+// Sanitize turns a device name, unit, container, path or identifier into
+// a key suffix: lowercase letters and digits separated by single underscores.
+func Sanitize(s string) string {
+	var b strings.Builder
+	underscore := false
+
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			underscore = false
+			continue
+		}
+		if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "_")
+}
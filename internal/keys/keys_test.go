@@ -0,0 +1,25 @@
+package keys
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"eth0", "eth0"},
+		{"nvme0n1", "nvme0n1"},
+		{"backup.timer", "backup_timer"},
+		{"/home/user", "home_user"},
+		{"My Container--1", "my_container_1"},
+		{"__x__", "x"},
+		{"GPU 0", "gpu_0"},
+		{"éth", "th"},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.in); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
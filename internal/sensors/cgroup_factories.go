@@ -0,0 +1,308 @@
+package sensors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+// cgroupRoot is the mount point of the cgroup v2 hierarchy.
+// Sensors build their paths from it so they can be pointed at a fake tree.
+var cgroupRoot = "/sys/fs/cgroup"
+
+const (
+	cgroupCPU            = "cpu"
+	cgroupMemory         = "memory"
+	cgroupMemoryMax      = "memory_max"
+	cgroupIORead         = "io_read"
+	cgroupIOWrite        = "io_write"
+	cgroupCPUPressure    = "cpu_pressure"
+	cgroupMemoryPressure = "memory_pressure"
+	cgroupIOPressure     = "io_pressure"
+)
+
+var cgroupMetrics = []string{
+	cgroupCPU, cgroupMemory, cgroupMemoryMax, cgroupIORead, cgroupIOWrite,
+	cgroupCPUPressure, cgroupMemoryPressure, cgroupIOPressure,
+}
+
+var cgroupMetricNames = map[string]string{
+	cgroupCPU:            "CPU",
+	cgroupMemory:         "memory",
+	cgroupMemoryMax:      "memory limit",
+	cgroupIORead:         "read",
+	cgroupIOWrite:        "write",
+	cgroupCPUPressure:    "CPU pressure",
+	cgroupMemoryPressure: "memory pressure",
+	cgroupIOPressure:     "I/O pressure",
+}
+
+// cgroupSample holds the cgroup files read at one collection.
+// Counters are cumulative; sensors turn them into rates.
+type cgroupSample struct {
+	cpuUsec   uint64
+	memory    uint64
+	memoryMax uint64
+	// unlimited is set when memory.max is "max".
+	unlimited  bool
+	readBytes  uint64
+	writeBytes uint64
+	// stallUsec is the "some" stall time by pressure file.
+	stallUsec map[string]uint64
+	// errs holds read errors by metric, so a file missing for one
+	// controller does not affect the other metrics.
+	errs map[string]error
+}
+
+type cgroupSensor struct {
+	base
+	cgroup string
+	// snapshot holds the last two reads of the cgroup files, so counters
+	// can be turned into rates between them.
+	snapshot *tickCache[counterWindow[cgroupSample]]
+	metric   string
+}
+
+func newCgroupSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	if cfg.Cgroup == "" {
+		return nil, errors.New("cgroup must be set to a cgroup v2 path (e.g. system.slice/nginx.service)")
+	}
+
+	metrics, err := selectMetrics(cfg.Metrics, cgroupMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(cgroupRoot, filepath.Clean("/"+cfg.Cgroup))
+
+	// Memory metrics are gauges; the others compare two reads.
+	rates := slices.ContainsFunc(metrics, func(m string) bool {
+		return m != cgroupMemory && m != cgroupMemoryMax
+	})
+	snapshot := newCounterCache(cfg.Interval, rates, func(context.Context) (cgroupSample, error) {
+		return readCgroup(dir, metrics)
+	})
+
+	out := make([]Sensor, 0, len(metrics))
+	for _, m := range metrics {
+		sKey := key + "_" + m
+		sName := fmt.Sprintf("%s %s", cfg.Name, cgroupMetricNames[m])
+
+		ha := haWithDefaults(cfg.HA, "%", "")
+		switch m {
+		case cgroupMemory, cgroupMemoryMax:
+			ha = haWithDefaults(cfg.HA, "B", "data_size")
+		case cgroupIORead, cgroupIOWrite:
+			ha = haWithDefaults(cfg.HA, "B/s", "data_rate")
+		}
+
+		out = append(out, &cgroupSensor{
+			base:     base{key: sKey, name: sName, interval: cfg.Interval, ha: ha},
+			cgroup:   cfg.Cgroup,
+			snapshot: snapshot,
+			metric:   m,
+		})
+	}
+	return out, nil
+}
+
+func (s *cgroupSensor) Collect(ctx context.Context) (Reading, error) {
+	w, _, err := s.snapshot.get(ctx)
+	if err == nil {
+		err = w.cur.errs[s.metric]
+	}
+	if err != nil {
+		return unavailable(), fmt.Errorf("cgroup(%s): %w", s.cgroup, err)
+	}
+
+	switch s.metric {
+	case cgroupMemory:
+		return number(float64(w.cur.memory), 0), nil
+
+	case cgroupMemoryMax:
+		if w.cur.unlimited {
+			return unavailable(), nil
+		}
+		return number(float64(w.cur.memoryMax), 0), nil
+	}
+
+	cur, ok := s.counter(w.cur)
+	if !ok {
+		return unavailable(), fmt.Errorf("cgroup: unknown metric %q", s.metric)
+	}
+	// The previous read may have failed, e.g. while the unit was stopped.
+	if w.prev.errs[s.metric] != nil {
+		return unavailable(), nil
+	}
+	prev, _ := s.counter(w.prev)
+
+	// Counters going backwards mean the cgroup was recreated, e.g. a
+	// restarted unit; the next window starts over.
+	if cur < prev {
+		return unavailable(), nil
+	}
+	rate, ok := w.perSecond(float64(cur - prev))
+	if !ok {
+		return unavailable(), nil
+	}
+
+	switch s.metric {
+	case cgroupIORead, cgroupIOWrite:
+		return number(rate, 0), nil
+	case cgroupCPU:
+		// 100% is one fully used core.
+		return number(rate/1e6*100, 1), nil
+	default:
+		// Share of time at least one task was stalled.
+		return number(min(rate/1e6*100, 100), 1), nil
+	}
+}
+
+// counter returns the cumulative counter of a rate metric.
+func (s *cgroupSensor) counter(sample cgroupSample) (uint64, bool) {
+	switch s.metric {
+	case cgroupCPU:
+		return sample.cpuUsec, true
+	case cgroupIORead:
+		return sample.readBytes, true
+	case cgroupIOWrite:
+		return sample.writeBytes, true
+	case cgroupCPUPressure, cgroupMemoryPressure, cgroupIOPressure:
+		return sample.stallUsec[cgroupPressureFile(s.metric)], true
+	default:
+		return 0, false
+	}
+}
+
+// cgroupPressureFile returns the pressure file of a pressure metric.
+func cgroupPressureFile(metric string) string {
+	return strings.TrimSuffix(metric, "_pressure") + ".pressure"
+}
+
+// readCgroup reads only the files of dir needed by metrics.
+func readCgroup(dir string, metrics []string) (cgroupSample, error) {
+	var out cgroupSample
+
+	// A stopped unit has no cgroup; report it rather than a missing file.
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return out, errors.New("cgroup not found")
+		}
+		return out, err
+	}
+
+	var ioErr error
+	readIO := false
+	for _, m := range metrics {
+		var err error
+		switch m {
+		case cgroupCPU:
+			out.cpuUsec, err = readCgroupKey(filepath.Join(dir, "cpu.stat"), "usage_usec")
+
+		case cgroupMemory:
+			out.memory, err = readSysfsUint(filepath.Join(dir, "memory.current"))
+
+		case cgroupMemoryMax:
+			var s string
+			s, err = readSysfsString(filepath.Join(dir, "memory.max"))
+			if err == nil {
+				if s == "max" {
+					out.unlimited = true
+				} else {
+					out.memoryMax, err = strconv.ParseUint(s, 10, 64)
+				}
+			}
+
+		case cgroupIORead, cgroupIOWrite:
+			if !readIO {
+				readIO = true
+				out.readBytes, out.writeBytes, ioErr = readCgroupIO(filepath.Join(dir, "io.stat"))
+			}
+			err = ioErr
+
+		case cgroupCPUPressure, cgroupMemoryPressure, cgroupIOPressure:
+			file := cgroupPressureFile(m)
+			var psi psiStats
+			psi, err = readPSI(filepath.Join(dir, file))
+			if err == nil {
+				if out.stallUsec == nil {
+					out.stallUsec = make(map[string]uint64, 3)
+				}
+				out.stallUsec[file] = psi.Some.Total
+			}
+		}
+
+		if err != nil {
+			if out.errs == nil {
+				out.errs = make(map[string]error)
+			}
+			// Files missing in an existing cgroup mean a controller not enabled
+			// for it, or pressure stall information disabled in the kernel.
+			if errors.Is(err, fs.ErrNotExist) {
+				err = fmt.Errorf("%s not available: %w", cgroupMetricNames[m], err)
+			}
+			out.errs[m] = err
+		}
+	}
+	return out, nil
+}
+
+// readCgroupKey returns a value of a flat keyed file such as cpu.stat.
+func readCgroupKey(path, key string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), " ")
+		if ok && k == key {
+			return strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("%s not found in %s", key, path)
+}
+
+// readCgroupIO returns bytes read and written by all devices in io.stat:
+//
+//	8:0 rbytes=1459200 wbytes=314773504 rios=192 wios=353 dbytes=0 dios=0
+func readCgroupIO(path string) (uint64, uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var read, written uint64
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		for _, f := range fields[1:] {
+			k, v, _ := strings.Cut(f, "=")
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch k {
+			case "rbytes":
+				read += n
+			case "wbytes":
+				written += n
+			}
+		}
+	}
+	return read, written, nil
+}
@@ -0,0 +1,127 @@
+package sensors
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+// useFakeCgroupfs points cgroup sensors at an empty tree for the duration
+// of the test and returns its root.
+func useFakeCgroupfs(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	prev := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = prev })
+	return root
+}
+
+func TestCgroupSensors(t *testing.T) {
+	dir := filepath.Join(useFakeCgroupfs(t), "system.slice", "nginx.service")
+
+	writeCounters := func(cpuUsec, readBytes, stallUsec string) {
+		writeFile(t, filepath.Join(dir, "cpu.stat"), "usage_usec "+cpuUsec+"\nuser_usec 1\nsystem_usec 2\n")
+		writeFile(t, filepath.Join(dir, "io.stat"),
+			"8:0 rbytes="+readBytes+" wbytes=10 rios=1 wios=1 dbytes=0 dios=0\n"+
+				"8:16 rbytes="+readBytes+" wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n")
+		writeFile(t, filepath.Join(dir, "memory.pressure"),
+			"some avg10=0.00 avg60=0.00 avg300=0.00 total="+stallUsec+"\n"+
+				"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")
+	}
+	writeCounters("1000000", "0", "0")
+	writeFile(t, filepath.Join(dir, "memory.current"), "1048576\n")
+	writeFile(t, filepath.Join(dir, "memory.max"), "max\n")
+
+	// Paths cannot escape the cgroup root.
+	// The first collection waits for a full interval after the baseline.
+	const interval = 100 * time.Millisecond
+	got, err := newCgroupSensors("ng", config.SensorConfig{
+		Name:     "Nginx",
+		Interval: interval,
+		Cgroup:   "../system.slice/nginx.service",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Counters move between the startup baseline and the first collection.
+	writeCounters("1100000", "1000", "50000")
+
+	readings := make(map[string]Reading)
+	errs := make(map[string]error)
+	for _, s := range got {
+		readings[s.Key()], errs[s.Key()] = s.Collect(context.Background())
+	}
+
+	want := map[string]Reading{
+		"ng_memory":     number(1048576, 0),
+		"ng_memory_max": unavailable(),
+		// No cpu.pressure or io.pressure: the controller files are missing.
+		"ng_cpu_pressure": unavailable(),
+		"ng_io_pressure":  unavailable(),
+	}
+	for k, w := range want {
+		if readings[k] != w {
+			t.Errorf("%s = %+v, want %+v", k, readings[k], w)
+		}
+	}
+	for _, k := range []string{"ng_cpu_pressure", "ng_io_pressure"} {
+		if errs[k] == nil {
+			t.Errorf("%s: expected a missing file error", k)
+		}
+	}
+
+	// Rates over at least one interval: 0.1s of CPU time, 2000 bytes read
+	// from two devices and 0.05s stalled.
+	maxRate := map[string]float64{
+		"ng_cpu":             0.1 / interval.Seconds() * 100,
+		"ng_io_read":         2000 / interval.Seconds(),
+		"ng_io_write":        0,
+		"ng_memory_pressure": min(0.05/interval.Seconds()*100, 100),
+	}
+	for k, m := range maxRate {
+		r := readings[k]
+		if errs[k] != nil || r.Kind != ReadingNumber || r.Value < 0 || r.Value > m || (m > 0 && r.Value == 0) {
+			t.Errorf("%s = %+v (err %v), want a rate in (0, %g]", k, r, errs[k], m)
+		}
+	}
+}
+
+func TestCgroupSensorsStoppedUnit(t *testing.T) {
+	useFakeCgroupfs(t)
+
+	got, err := newCgroupSensors("ng", config.SensorConfig{
+		Interval: time.Minute,
+		Cgroup:   "system.slice/nginx.service",
+		Metrics:  []string{"memory"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := got[0].Collect(context.Background())
+	if err == nil || r.Available() {
+		t.Fatalf("got %+v, %v; want unavailable with an error", r, err)
+	}
+	if want := "cgroup(system.slice/nginx.service): cgroup not found"; err.Error() != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+}
+
+func TestReadCgroupIO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "io.stat")
+	writeFile(t, path, "8:0 rbytes=100 wbytes=200 rios=1 wios=2\n253:0 rbytes=1 wbytes=2 dbytes=3\nbad\n")
+
+	read, written, err := readCgroupIO(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != 101 || written != 202 {
+		t.Errorf("got read=%d written=%d, want 101 and 202", read, written)
+	}
+}
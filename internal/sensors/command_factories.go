@@ -0,0 +1,75 @@
+package sensors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+// commandSensor runs an executable (without a shell) on every collection
+// and extracts the value from its standard output.
+type commandSensor struct {
+	base
+	command   []string
+	timeout   time.Duration
+	extractor *valueExtractor
+}
+
+func newCommandSensor(key string, cfg config.SensorConfig) (Sensor, error) {
+	if len(cfg.Command) == 0 {
+		return nil, errors.New("command must contain at least one item (executable name)")
+	}
+
+	extractor, err := newValueExtractor(cfg.Regex, cfg.JSONPath, cfg.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sensors of an interval group are collected one after another, so a
+	// hanging command delays the others; it must also finish before the
+	// next tick.
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	timeout = min(timeout, cfg.Interval)
+
+	return &commandSensor{
+		base:      base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+		command:   cfg.Command,
+		timeout:   timeout,
+		extractor: extractor,
+	}, nil
+}
+
+func (s *commandSensor) Collect(parent context.Context) (Reading, error) {
+	ctx, cancel := context.WithTimeout(parent, s.timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return unavailable(), fmt.Errorf("command(%s): timeout exceeded (%s)", s.command[0], s.timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return unavailable(), fmt.Errorf("command(%s): %w: %s", s.command[0], err, msg)
+		}
+		return unavailable(), fmt.Errorf("command(%s): %w", s.command[0], err)
+	}
+
+	r, err := s.extractor.extract(out)
+	if err != nil {
+		return unavailable(), fmt.Errorf("command(%s): %w", s.command[0], err)
+	}
+	return r, nil
+}
@@ -0,0 +1,83 @@
+package sensors
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestCommandSensorTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		timeout  time.Duration
+		want     time.Duration
+	}{
+		{name: "default", interval: time.Minute, want: 10 * time.Second},
+		{name: "default above interval", interval: 5 * time.Second, want: 5 * time.Second},
+		{name: "configured", interval: time.Minute, timeout: 30 * time.Second, want: 30 * time.Second},
+		{name: "configured above interval", interval: time.Minute, timeout: 2 * time.Minute, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newCommandSensor("cmd", config.SensorConfig{
+				Interval: tt.interval,
+				Timeout:  tt.timeout,
+				Command:  []string{"true"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.(*commandSensor).timeout; got != tt.want {
+				t.Errorf("got timeout %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandSensorCollect(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		regex   string
+		timeout time.Duration
+		want    Reading
+		wantErr string
+	}{
+		{name: "output", command: []string{"sh", "-c", "echo 42.50"}, want: number(42.5, 2)},
+		{name: "regex", command: []string{"sh", "-c", "echo used 83%"}, regex: `(\d+)%`, want: number(83, 0)},
+		{name: "exit status with stderr", command: []string{"sh", "-c", "echo no pool >&2; exit 1"}, wantErr: "exit status 1: no pool"},
+		{name: "timeout", command: []string{"sleep", "5"}, timeout: 50 * time.Millisecond, wantErr: "timeout exceeded (50ms)"},
+		{name: "missing executable", command: []string{"/nonexistent/gometrum-test"}, wantErr: "no such file or directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newCommandSensor("cmd", config.SensorConfig{
+				Interval: time.Minute,
+				Timeout:  tt.timeout,
+				Command:  tt.command,
+				Regex:    tt.regex,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.Collect(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/container"
+	"github.com/Miklakapi/gometrum/internal/keys"
+)
+
+const (
+	containerState    = "state"
+	containerCPU      = "cpu"
+	containerMemory   = "memory"
+	containerRestarts = "restarts"
+	containerHealth   = "health"
+)
+
+var containerMetrics = []string{containerState, containerCPU, containerMemory, containerRestarts, containerHealth}
+
+var containerMetricNames = map[string]string{
+	containerState:    "state",
+	containerCPU:      "CPU",
+	containerMemory:   "memory",
+	containerRestarts: "restarts",
+	containerHealth:   "health",
+}
+
+type containerSensor struct {
+	base
+	container string
+	// snapshot holds the last two reads of the container, so the CPU
+	// usage can be computed between them.
+	snapshot *tickCache[counterWindow[containerSample]]
+	metric   string
+}
+
+// newContainerSensors creates sensors per listed container and metric.
+// Containers are looked up by name on every collection, so the engine does
+// not have to be up at startup and a container may be created later.
+func newContainerSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	if len(cfg.Containers) == 0 {
+		return nil, errors.New("containers must list at least one container")
+	}
+
+	metrics, err := selectMetrics(cfg.Metrics, containerMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	client := container.NewClient(cfg.Socket, min(timeout, cfg.Interval))
+
+	names := append([]string(nil), cfg.Containers...)
+	sort.Strings(names)
+
+	needStats := slices.Contains(metrics, containerCPU) || slices.Contains(metrics, containerMemory)
+
+	out := make([]Sensor, 0, len(names)*len(metrics))
+	for _, n := range names {
+		snapshot := newCounterCache(cfg.Interval, slices.Contains(metrics, containerCPU), func(ctx context.Context) (containerSample, error) {
+			return readContainer(ctx, client, n, needStats)
+		})
+
+		for _, m := range metrics {
+			sKey := key + "_" + keys.Sanitize(n) + "_" + m
+			sName := fmt.Sprintf("%s %s %s", cfg.Name, n, containerMetricNames[m])
+
+			ha := cfg.HA
+			switch m {
+			case containerCPU:
+				ha = haWithStateClass(haWithDefaults(cfg.HA, "%", ""), "measurement")
+			case containerMemory:
+				ha = haWithStateClass(haWithDefaults(cfg.HA, "B", "data_size"), "measurement")
+			}
+
+			s := &containerSensor{
+				base:      base{key: sKey, name: sName, interval: cfg.Interval, ha: ha},
+				container: n,
+				snapshot:  snapshot,
+				metric:    m,
+			}
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (s *containerSensor) Collect(ctx context.Context) (Reading, error) {
+	w, _, err := s.snapshot.get(ctx)
+	if err != nil {
+		return unavailable(), fmt.Errorf("container(%s): %w", s.container, err)
+	}
+	snap := w.cur
+
+	switch s.metric {
+	case containerState:
+		return text(snap.info.State.Status), nil
+
+	case containerRestarts:
+		return number(float64(snap.info.RestartCount), 0), nil
+
+	case containerHealth:
+		// Containers without a health check have no health state.
+		if snap.info.State.Health == nil || snap.info.State.Health.Status == "" {
+			return unavailable(), nil
+		}
+		return text(snap.info.State.Health.Status), nil
+
+	case containerMemory:
+		if !snap.info.State.Running {
+			return unavailable(), nil
+		}
+		return number(float64(snap.stats.MemoryUsed()), 0), nil
+
+	case containerCPU:
+		if !snap.info.State.Running || !w.prev.info.State.Running {
+			return unavailable(), nil
+		}
+
+		// Counters going backwards mean the container was restarted;
+		// the next window starts over.
+		cur, prev := snap.stats.CPUStats.CPUUsage.TotalUsage, w.prev.stats.CPUStats.CPUUsage.TotalUsage
+		if cur < prev {
+			return unavailable(), nil
+		}
+
+		// CPU usage is counted in nanoseconds.
+		rate, ok := w.perSecond(float64(cur-prev) / 1e9)
+		if !ok {
+			return unavailable(), nil
+		}
+		return number(rate*100, 1), nil
+
+	default:
+		return unavailable(), fmt.Errorf("container: unknown metric %q", s.metric)
+	}
+}
+
+type containerSample struct {
+	info  container.Info
+	stats container.Stats
+}
+
+// readContainer inspects a container, and reads its resource usage
+// when stats is set and the container is running.
+func readContainer(ctx context.Context, client *container.Client, name string, stats bool) (containerSample, error) {
+	var out containerSample
+
+	info, err := client.Inspect(ctx, name)
+	if err != nil {
+		if errors.Is(err, container.ErrNotFound) {
+			return out, err
+		}
+		return out, fmt.Errorf("inspect failed: %w", err)
+	}
+	out.info = info
+
+	if stats && info.State.Running {
+		st, err := client.Stats(ctx, name)
+		if err != nil {
+			return out, fmt.Errorf("stats failed: %w", err)
+		}
+		out.stats = st
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package sensors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+// fakeEngine serves a Docker Engine API with a running "web" container,
+// which uses 10ms of CPU time per stats request, and a stopped "db".
+func fakeEngine(t *testing.T) (socket string, stats *atomic.Int64) {
+	t.Helper()
+
+	stats = new(atomic.Int64)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/web/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"RestartCount":1,"State":{"Status":"running","Running":true,"Health":{"Status":"healthy"}}}`))
+	})
+	mux.HandleFunc("GET /containers/db/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"RestartCount":0,"State":{"Status":"exited","Running":false}}`))
+	})
+	mux.HandleFunc("GET /containers/web/stats", func(w http.ResponseWriter, r *http.Request) {
+		n := stats.Add(1)
+		fmt.Fprintf(w, `{"cpu_stats":{"cpu_usage":{"total_usage":%d}},"memory_stats":{"usage":2048,"stats":{"inactive_file":1024}}}`, n*10_000_000)
+	})
+
+	socket = filepath.Join(t.TempDir(), "engine.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return socket, stats
+}
+
+func TestContainerSensors(t *testing.T) {
+	socket, stats := fakeEngine(t)
+
+	// The first collection waits for a full interval after the baseline.
+	const interval = 100 * time.Millisecond
+	got, err := newContainerSensors("ctr", config.SensorConfig{
+		Name:       "Container",
+		Interval:   interval,
+		Socket:     socket,
+		Containers: []string{"db", "web"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := stats.Load(); n != 1 {
+		t.Fatalf("got %d baseline stats requests, want 1", n)
+	}
+
+	readings := make(map[string]Reading, len(got))
+	for _, s := range got {
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		readings[s.Key()] = r
+
+		if ha := s.HA(); (s.Key() == "ctr_web_cpu" || s.Key() == "ctr_web_memory") && ha.StateClass != "measurement" {
+			t.Errorf("%s: state_class %q, want measurement", s.Key(), ha.StateClass)
+		}
+	}
+
+	want := map[string]Reading{
+		"ctr_db_state":     text("exited"),
+		"ctr_db_cpu":       unavailable(),
+		"ctr_db_memory":    unavailable(),
+		"ctr_db_restarts":  number(0, 0),
+		"ctr_db_health":    unavailable(),
+		"ctr_web_state":    text("running"),
+		"ctr_web_memory":   number(1024, 0),
+		"ctr_web_restarts": number(1, 0),
+		"ctr_web_health":   text("healthy"),
+	}
+	for k, w := range want {
+		if readings[k] != w {
+			t.Errorf("%s = %+v, want %+v", k, readings[k], w)
+		}
+	}
+
+	// 10ms of CPU time over at least one interval, from the startup baseline.
+	cpu := readings["ctr_web_cpu"]
+	if max := 0.01 / interval.Seconds() * 100; cpu.Kind != ReadingNumber || cpu.Value <= 0 || cpu.Value > max {
+		t.Errorf("ctr_web_cpu = %+v, want a value in (0, %g]", cpu, max)
+	}
+}
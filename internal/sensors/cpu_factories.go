@@ -2,12 +2,15 @@ package sensors
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Miklakapi/gometrum/internal/config"
 
-	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/load"
 	gsensors "github.com/shirou/gopsutil/v4/sensors"
 )
@@ -32,62 +35,194 @@ func newCPULoadSensor(key string, cfg config.SensorConfig, window loadWindow) Se
 	}
 }
 
-func (s *cpuLoadSensor) Collect(ctx context.Context) (string, error) {
+func (s *cpuLoadSensor) Collect(ctx context.Context) (Reading, error) {
 	avg, err := load.AvgWithContext(ctx)
 	if err != nil {
-		return "unavailable", err
+		return unavailable(), err
 	}
 
 	switch s.window {
 	case loadWindow1m:
-		return fmt.Sprintf("%.2f", avg.Load1), nil
+		return number(avg.Load1, 2), nil
 	case loadWindow5m:
-		return fmt.Sprintf("%.2f", avg.Load5), nil
+		return number(avg.Load5, 2), nil
 	case loadWindow15m:
-		return fmt.Sprintf("%.2f", avg.Load15), nil
+		return number(avg.Load15, 2), nil
 	default:
-		return "unavailable", fmt.Errorf("cpu_load: unknown window")
+		return unavailable(), fmt.Errorf("cpu_load: unknown window")
 	}
 }
 
+type cpuTimeField uint8
+
+const (
+	cpuTimeBusy cpuTimeField = iota
+	cpuTimeUser
+	cpuTimeSystem
+	cpuTimeIowait
+	cpuTimeSteal
+	cpuTimeIrq
+)
+
+// cpuUsageSensor reports the share of CPU time spent in one category
+// between two consecutive reads of /proc/stat.
 type cpuUsageSensor struct {
 	base
+	times   *tickCache[counterWindow[map[string]cpuTimes]]
+	cpuName string
+	field   cpuTimeField
 }
 
-func newCPUUsageSensor(key string, cfg config.SensorConfig) Sensor {
+func newCPUUsageSensor(key string, cfg config.SensorConfig, field cpuTimeField) Sensor {
 	return &cpuUsageSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+		base:    base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+		times:   newCPUTimesCache(cfg.Interval),
+		cpuName: "cpu",
+		field:   field,
+	}
+}
+
+func newCPUCoreUsageSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	times, err := readCPUTimes()
+	if err != nil {
+		return nil, fmt.Errorf("cpu_core_usage: list cores failed: %w", err)
+	}
+
+	cores := append([]int(nil), cfg.IncludeCores...)
+	for _, c := range cores {
+		if _, ok := times["cpu"+strconv.Itoa(c)]; !ok {
+			return nil, fmt.Errorf("include_cores: core %d not found in %s", c, procStatPath)
+		}
+	}
+
+	if len(cores) == 0 {
+		for name := range times {
+			n, err := strconv.Atoi(strings.TrimPrefix(name, "cpu"))
+			if err != nil {
+				continue
+			}
+			cores = append(cores, n)
+		}
+	}
+
+	sort.Ints(cores)
+
+	cache := newCPUTimesCache(cfg.Interval)
+
+	out := make([]Sensor, 0, len(cores))
+	for _, c := range cores {
+		sKey := fmt.Sprintf("%s_%d", key, c)
+		sName := fmt.Sprintf("%s %d", cfg.Name, c)
+
+		out = append(out, &cpuUsageSensor{
+			base:    base{key: sKey, name: sName, interval: cfg.Interval, ha: cfg.HA},
+			times:   cache,
+			cpuName: "cpu" + strconv.Itoa(c),
+			field:   cpuTimeBusy,
+		})
 	}
+
+	return out, nil
+}
+
+func newCPUTimesCache(interval time.Duration) *tickCache[counterWindow[map[string]cpuTimes]] {
+	return newCounterCache(interval, true, func(context.Context) (map[string]cpuTimes, error) {
+		return readCPUTimes()
+	})
 }
 
-func (s *cpuUsageSensor) Collect(ctx context.Context) (string, error) {
-	vals, err := cpu.Percent(0, false)
+func (s *cpuUsageSensor) Collect(ctx context.Context) (Reading, error) {
+	w, _, err := s.times.get(ctx)
 	if err != nil {
-		return "unavailable", err
+		return unavailable(), fmt.Errorf("cpu_usage(%s): %w", s.cpuName, err)
+	}
+
+	cur, ok := w.cur[s.cpuName]
+	if !ok {
+		return unavailable(), fmt.Errorf("cpu_usage(%s): not found in %s", s.cpuName, procStatPath)
+	}
+
+	// Counters going backwards (e.g. a CPU taken offline and back)
+	// leave one window without a value.
+	prev, ok := w.prev[s.cpuName]
+	if !ok || cur.total() <= prev.total() {
+		return unavailable(), nil
+	}
+
+	return number(cpuTimePercent(prev, cur, s.field), 1), nil
+}
+
+func cpuTimePercent(prev, cur cpuTimes, field cpuTimeField) float64 {
+	total := float64(cur.total() - prev.total())
+
+	var part float64
+	switch field {
+	case cpuTimeUser:
+		part = counterDelta(prev.User, cur.User)
+	case cpuTimeSystem:
+		part = counterDelta(prev.System, cur.System)
+	case cpuTimeIowait:
+		part = counterDelta(prev.Iowait, cur.Iowait)
+	case cpuTimeSteal:
+		part = counterDelta(prev.Steal, cur.Steal)
+	case cpuTimeIrq:
+		part = counterDelta(prev.Irq, cur.Irq) + counterDelta(prev.Softirq, cur.Softirq)
+	default:
+		idle := counterDelta(prev.Idle, cur.Idle) + counterDelta(prev.Iowait, cur.Iowait)
+		part = total - idle
+	}
+
+	switch {
+	case part <= 0:
+		return 0
+	case part >= total:
+		return 100
+	default:
+		return part / total * 100.0
 	}
-	if len(vals) == 0 {
-		return "0.0", nil
+}
+
+// counterDelta returns the increase of a monotonic counter, or 0 if it was reset.
+func counterDelta(prev, cur uint64) float64 {
+	if cur < prev {
+		return 0
 	}
-	return fmt.Sprintf("%.1f", vals[0]), nil
+	return float64(cur - prev)
 }
 
 type cpuTempSensor struct {
 	base
+	filter    hwmonFilter
+	aggregate string
 }
 
-func newCPUTempSensor(key string, cfg config.SensorConfig) Sensor {
-	return &cpuTempSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+func newCPUTempSensor(key string, cfg config.SensorConfig) (Sensor, error) {
+	if err := validateTempOptions(cfg); err != nil {
+		return nil, err
 	}
+	// Without chip and label a single sensor is picked; nothing to combine.
+	if cfg.Aggregate != "" && cfg.Chip == "" && cfg.Label == "" {
+		return nil, errors.New("aggregate requires chip or label")
+	}
+
+	return &cpuTempSensor{
+		base:      base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+		filter:    hwmonFilter{chip: cfg.Chip, label: cfg.Label},
+		aggregate: cfg.Aggregate,
+	}, nil
 }
 
-func (s *cpuTempSensor) Collect(ctx context.Context) (string, error) {
+func (s *cpuTempSensor) Collect(ctx context.Context) (Reading, error) {
+	if s.filter.chip != "" || s.filter.label != "" {
+		return s.collectHwmon()
+	}
+
 	temps, err := gsensors.SensorsTemperatures()
 	if err != nil {
-		return "unavailable", err
+		return unavailable(), err
 	}
 	if len(temps) == 0 {
-		return "unavailable", fmt.Errorf("cpu_temp: no temperature sensors")
+		return unavailable(), fmt.Errorf("cpu_temp: no temperature sensors")
 	}
 
 	best := temps[0]
@@ -102,5 +237,40 @@ func (s *cpuTempSensor) Collect(ctx context.Context) (string, error) {
 		}
 	}
 
-	return fmt.Sprintf("%.1f", best.Temperature), nil
+	return number(best.Temperature, 1), nil
+}
+
+func (s *cpuTempSensor) collectHwmon() (Reading, error) {
+	temps, err := listHwmonTemps()
+	if err != nil {
+		return unavailable(), fmt.Errorf("cpu_temp: %w", err)
+	}
+
+	matched := make([]hwmonTemp, 0, len(temps))
+	for _, t := range temps {
+		if s.filter.match(t) {
+			matched = append(matched, t)
+		}
+	}
+	if len(matched) == 0 {
+		return unavailable(), fmt.Errorf("cpu_temp: no hwmon input matches chip=%q label=%q", s.filter.chip, s.filter.label)
+	}
+
+	v, err := aggregateTemps(matched, s.aggregate)
+	if err != nil {
+		return unavailable(), fmt.Errorf("cpu_temp: %w", err)
+	}
+	return number(v, 1), nil
+}
+
+func validateTempOptions(cfg config.SensorConfig) error {
+	switch cfg.Aggregate {
+	case "", "max", "avg":
+	default:
+		return fmt.Errorf("aggregate must be one of: max, avg (got: %s)", cfg.Aggregate)
+	}
+	if err := validateHwmonPattern("chip", cfg.Chip); err != nil {
+		return err
+	}
+	return validateHwmonPattern("label", cfg.Label)
 }
@@ -0,0 +1,94 @@
+package sensors
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func useFakeProcStat(t *testing.T, stat string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "stat")
+	writeFile(t, path, stat)
+
+	prev := procStatPath
+	procStatPath = path
+	t.Cleanup(func() { procStatPath = prev })
+	return path
+}
+
+func TestCPUCoreUsageSensors(t *testing.T) {
+	path := useFakeProcStat(t, "cpu  200 0 0 800 0 0 0 0\n"+
+		"cpu0 100 0 0 400 0 0 0 0\n"+
+		"cpu1 100 0 0 400 0 0 0 0\n"+
+		"cpu10 0 0 0 0 0 0 0 0\n")
+
+	const interval = 50 * time.Millisecond
+	got, err := newCPUCoreUsageSensors("core", config.SensorConfig{Name: "Core", Interval: interval, IncludeCores: []int{1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys, names []string
+	for _, s := range got {
+		keys = append(keys, s.Key())
+		names = append(names, s.Name())
+	}
+	if want := []string{"core_0", "core_1"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+	if want := []string{"Core 0", "Core 1"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+
+	// cpu0 busy for 50 of 100 ticks, cpu1 idle.
+	writeFile(t, path, "cpu  250 0 0 950 0 0 0 0\n"+
+		"cpu0 150 0 0 450 0 0 0 0\n"+
+		"cpu1 100 0 0 500 0 0 0 0\n")
+
+	want := []Reading{number(50, 1), number(0, 1)}
+	for i, s := range got {
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		if r != want[i] {
+			t.Errorf("%s = %+v, want %+v", s.Key(), r, want[i])
+		}
+	}
+}
+
+func TestCPUCoreUsageSensorsAllCores(t *testing.T) {
+	useFakeProcStat(t, "cpu  0 0 0 0 0 0 0 0\n"+
+		"cpu2 0 0 0 0 0 0 0 0\n"+
+		"cpu10 0 0 0 0 0 0 0 0\n")
+
+	got, err := newCPUCoreUsageSensors("core", config.SensorConfig{Name: "Core", Interval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, s := range got {
+		keys = append(keys, s.Key())
+	}
+	// Cores are ordered by number, not by name.
+	if want := []string{"core_2", "core_10"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+}
+
+func TestCPUCoreUsageSensorsUnknownCore(t *testing.T) {
+	useFakeProcStat(t, "cpu  0 0 0 0 0 0 0 0\ncpu0 0 0 0 0 0 0 0 0\n")
+
+	_, err := newCPUCoreUsageSensors("core", config.SensorConfig{Interval: time.Minute, IncludeCores: []int{0, 4}})
+	if err == nil || !strings.Contains(err.Error(), "include_cores: core 4 not found") {
+		t.Errorf("got %v, want an error for core 4", err)
+	}
+}
@@ -2,11 +2,13 @@ package sensors
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/keys"
 	"github.com/shirou/gopsutil/v4/disk"
 )
 
@@ -33,21 +35,40 @@ func newDiskUsageSensors(key string, cfg config.SensorConfig) []Sensor {
 			sName = fmt.Sprintf("%s %s", cfg.Name, m)
 		}
 
-		out = append(out, &diskUsageSensor{
+		s := &diskUsageSensor{
 			base:  base{key: sKey, name: sName, interval: cfg.Interval, ha: cfg.HA},
 			mount: m,
-		})
+		}
+		if cfg.Attributes {
+			out = append(out, withAttributes{s})
+			continue
+		}
+		out = append(out, s)
 	}
 
 	return out
 }
 
-func (s *diskUsageSensor) Collect(ctx context.Context) (string, error) {
+func (s *diskUsageSensor) Collect(ctx context.Context) (Reading, error) {
+	r, _, err := s.collect(ctx)
+	return r, err
+}
+
+// collect also returns capacity in bytes and the filesystem type as attributes.
+func (s *diskUsageSensor) collect(ctx context.Context) (Reading, map[string]any, error) {
 	u, err := disk.UsageWithContext(ctx, s.mount)
 	if err != nil {
-		return "unavailable", fmt.Errorf("disk_usage(%s): %w", s.mount, err)
+		return unavailable(), nil, fmt.Errorf("disk_usage(%s): %w", s.mount, err)
+	}
+
+	attrs := map[string]any{
+		"mount":       s.mount,
+		"fstype":      u.Fstype,
+		"total_bytes": u.Total,
+		"used_bytes":  u.Used,
+		"free_bytes":  u.Free,
 	}
-	return fmt.Sprintf("%.1f", u.UsedPercent), nil
+	return number(u.UsedPercent, 1), attrs, nil
 }
 
 func sanitizeMount(m string) string {
@@ -62,3 +83,128 @@ func sanitizeMount(m string) string {
 	}
 	return s
 }
+
+const (
+	diskIOReadBytes  = "read_bytes"
+	diskIOWriteBytes = "write_bytes"
+	diskIOReadIOPS   = "read_iops"
+	diskIOWriteIOPS  = "write_iops"
+	diskIOBusy       = "busy"
+)
+
+var diskIOMetrics = []string{diskIOReadBytes, diskIOWriteBytes, diskIOReadIOPS, diskIOWriteIOPS, diskIOBusy}
+
+var diskIOMetricNames = map[string]string{
+	diskIOReadBytes:  "read",
+	diskIOWriteBytes: "write",
+	diskIOReadIOPS:   "read IOPS",
+	diskIOWriteIOPS:  "write IOPS",
+	diskIOBusy:       "busy",
+}
+
+// diskIOSensor reports the rate of one block device counter between two
+// consecutive reads shared by the sensors of one configuration.
+type diskIOSensor struct {
+	base
+	counters *tickCache[counterWindow[map[string]disk.IOCountersStat]]
+	device   string
+	metric   string
+}
+
+func newDiskIOSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	if len(cfg.IncludeDevices) == 0 {
+		return nil, errors.New("include_devices must list at least one block device (e.g. sda, nvme0n1)")
+	}
+
+	metrics, err := selectMetrics(cfg.Metrics, diskIOMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := append([]string(nil), cfg.IncludeDevices...)
+	sort.Strings(devices)
+
+	counters := newCounterCache(cfg.Interval, true, func(ctx context.Context) (map[string]disk.IOCountersStat, error) {
+		return disk.IOCountersWithContext(ctx, devices...)
+	})
+
+	out := make([]Sensor, 0, len(devices)*len(metrics))
+	for _, d := range devices {
+		for _, m := range metrics {
+			sKey := key + "_" + keys.Sanitize(d) + "_" + m
+			sName := fmt.Sprintf("%s %s %s", cfg.Name, d, diskIOMetricNames[m])
+
+			ha := haWithDefaults(cfg.HA, "ops/s", "")
+			switch m {
+			case diskIOReadBytes, diskIOWriteBytes:
+				ha = haWithDefaults(cfg.HA, "B/s", "data_rate")
+			case diskIOBusy:
+				ha = haWithDefaults(cfg.HA, "%", "")
+			}
+
+			out = append(out, &diskIOSensor{
+				base:     base{key: sKey, name: sName, interval: cfg.Interval, ha: ha},
+				counters: counters,
+				device:   d,
+				metric:   m,
+			})
+		}
+	}
+
+	return out, nil
+}
+
+func (s *diskIOSensor) Collect(ctx context.Context) (Reading, error) {
+	w, _, err := s.counters.get(ctx)
+	if err != nil {
+		return unavailable(), fmt.Errorf("disk_io(%s): %w", s.device, err)
+	}
+
+	cur, ok := w.cur[s.device]
+	if !ok {
+		return unavailable(), fmt.Errorf("disk_io(%s): device not found", s.device)
+	}
+	prev, ok := w.prev[s.device]
+	if !ok {
+		return unavailable(), nil
+	}
+
+	// Counters going backwards mean the device was re-attached; the next
+	// window starts over.
+	curVal, prevVal := diskIOCounter(cur, s.metric), diskIOCounter(prev, s.metric)
+	if curVal < prevVal {
+		return unavailable(), nil
+	}
+
+	rate, ok := w.perSecond(float64(curVal - prevVal))
+	if !ok {
+		return unavailable(), nil
+	}
+
+	switch s.metric {
+	case diskIOBusy:
+		// io_ticks is the time in milliseconds the device had I/O in flight.
+		return number(min(rate/1000*100, 100), 1), nil
+	case diskIOReadBytes, diskIOWriteBytes:
+		return number(rate, 0), nil
+	default:
+		return number(rate, 1), nil
+	}
+}
+
+func diskIOCounter(c disk.IOCountersStat, metric string) uint64 {
+	switch metric {
+	case diskIOReadBytes:
+		return c.ReadBytes
+	case diskIOWriteBytes:
+		return c.WriteBytes
+	case diskIOReadIOPS:
+		return c.ReadCount
+	case diskIOWriteIOPS:
+		return c.WriteCount
+	case diskIOBusy:
+		return c.IoTime
+	default:
+		return 0
+	}
+}
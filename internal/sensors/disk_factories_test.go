@@ -0,0 +1,80 @@
+package sensors
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestDiskIOSensors(t *testing.T) {
+	proc := t.TempDir()
+	t.Setenv("HOST_PROC", proc)
+
+	// Fields after the name: reads, reads merged, sectors read, ms reading,
+	// writes, writes merged, sectors written, ms writing, in flight, io ms.
+	writeStats := func(reads, sectorsRead, writes, sectorsWritten, ioMs int) {
+		writeFile(t, filepath.Join(proc, "diskstats"), fmt.Sprintf(
+			"   8       0 sda %d 0 %d 0 %d 0 %d 0 0 %d 0\n"+
+				"   8      16 sdb 1 0 1 0 1 0 1 0 0 1 0\n",
+			reads, sectorsRead, writes, sectorsWritten, ioMs))
+	}
+	writeStats(100, 1000, 10, 100, 1000)
+
+	const interval = 100 * time.Millisecond
+	got, err := newDiskIOSensors("disk", config.SensorConfig{Name: "Disk", Interval: interval, IncludeDevices: []string{"sda"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantUnits := map[string]string{
+		"disk_sda_read_bytes":  "B/s",
+		"disk_sda_write_bytes": "B/s",
+		"disk_sda_read_iops":   "ops/s",
+		"disk_sda_write_iops":  "ops/s",
+		"disk_sda_busy":        "%",
+	}
+	if len(got) != len(wantUnits) {
+		t.Fatalf("got %d sensors, want %d", len(got), len(wantUnits))
+	}
+
+	// 100 reads of 1000 sectors, 10 writes of 100 sectors, busy for
+	// 10 s of wall time: more than the window, which caps busy at 100%.
+	writeStats(200, 2000, 20, 200, 11000)
+
+	readings := make(map[string]Reading, len(got))
+	for _, s := range got {
+		if want := wantUnits[s.Key()]; s.HA().Unit != want {
+			t.Errorf("%s unit = %q, want %q", s.Key(), s.HA().Unit, want)
+		}
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		readings[s.Key()] = r
+	}
+
+	maxRate := func(delta float64) float64 { return delta / interval.Seconds() }
+	for key, delta := range map[string]float64{
+		"disk_sda_read_bytes":  1000 * 512,
+		"disk_sda_write_bytes": 100 * 512,
+		"disk_sda_read_iops":   100,
+		"disk_sda_write_iops":  10,
+	} {
+		if r := readings[key]; r.Kind != ReadingNumber || r.Value <= 0 || r.Value > maxRate(delta) {
+			t.Errorf("%s = %+v, want a rate in (0, %g]", key, r, maxRate(delta))
+		}
+	}
+	if want := number(100, 1); readings["disk_sda_busy"] != want {
+		t.Errorf("disk_sda_busy = %+v, want %+v", readings["disk_sda_busy"], want)
+	}
+}
+
+func TestDiskIOSensorsRequireDevices(t *testing.T) {
+	if _, err := newDiskIOSensors("disk", config.SensorConfig{Interval: time.Minute}); err == nil {
+		t.Error("expected an error without include_devices")
+	}
+}
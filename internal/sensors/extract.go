@@ -0,0 +1,196 @@
+package sensors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// valueExtractor turns raw output of a command, file or HTTP response
+// into a reading. Without a selector the whole trimmed output is the value.
+type valueExtractor struct {
+	regex    *regexp.Regexp
+	jsonPath []jsonPathSegment
+	// field selects a whitespace-separated field, starting at 1.
+	field int
+}
+
+type jsonPathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+func newValueExtractor(regex, jsonPath string, field int) (*valueExtractor, error) {
+	selectors := 0
+	for _, set := range []bool{regex != "", jsonPath != "", field > 0} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors > 1 {
+		return nil, errors.New("regex, json_path and field are mutually exclusive")
+	}
+
+	e := &valueExtractor{field: field}
+
+	if regex != "" {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, fmt.Errorf("regex is invalid: %w", err)
+		}
+		if re.NumSubexp() > 1 {
+			return nil, errors.New("regex must have at most one capture group")
+		}
+		e.regex = re
+	}
+
+	if jsonPath != "" {
+		path, err := parseJSONPath(jsonPath)
+		if err != nil {
+			return nil, fmt.Errorf("json_path is invalid: %w", err)
+		}
+		e.jsonPath = path
+	}
+
+	return e, nil
+}
+
+func (e *valueExtractor) extract(raw []byte) (Reading, error) {
+	switch {
+	case e.regex != nil:
+		m := e.regex.FindSubmatch(raw)
+		if m == nil {
+			return unavailable(), errors.New("regex does not match")
+		}
+		return parseValue(string(m[len(m)-1])), nil
+
+	case e.jsonPath != nil:
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+
+		var doc any
+		if err := dec.Decode(&doc); err != nil {
+			return unavailable(), fmt.Errorf("decode json failed: %w", err)
+		}
+		return selectJSONValue(doc, e.jsonPath)
+
+	case e.field > 0:
+		fields := strings.Fields(string(raw))
+		if e.field > len(fields) {
+			return unavailable(), fmt.Errorf("field %d not found (%d fields)", e.field, len(fields))
+		}
+		return parseValue(fields[e.field-1]), nil
+
+	default:
+		return parseValue(string(raw)), nil
+	}
+}
+
+// parseValue returns a numeric reading for numbers, keeping the number
+// of decimals of the source, and a text reading otherwise.
+func parseValue(s string) Reading {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return unavailable()
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return text(s)
+	}
+
+	mantissa, exp, _ := strings.Cut(strings.ToLower(s), "e")
+
+	precision := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		precision = len(mantissa) - i - 1
+	}
+	// 1.5e-3 has four decimals, 1.2345e+10 none.
+	if e, err := strconv.Atoi(exp); err == nil {
+		precision -= e
+	}
+	return number(v, min(max(precision, 0), 10))
+}
+
+// parseJSONPath parses a dotted path with array indexes,
+// e.g. "pools[0].health" or "$.status".
+func parseJSONPath(p string) ([]jsonPathSegment, error) {
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+	if p == "" {
+		return nil, errors.New("path is empty")
+	}
+
+	var out []jsonPathSegment
+	for _, part := range strings.Split(p, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" && rest == "" {
+			return nil, fmt.Errorf("empty segment in %q", p)
+		}
+		if key != "" {
+			out = append(out, jsonPathSegment{key: key})
+		}
+
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("unterminated index in %q", part)
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index %q in %q", idx, part)
+			}
+			out = append(out, jsonPathSegment{index: n, isIdx: true})
+
+			if after != "" && !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("unexpected %q in %q", after, part)
+			}
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return out, nil
+}
+
+func selectJSONValue(doc any, path []jsonPathSegment) (Reading, error) {
+	cur := doc
+	for _, seg := range path {
+		switch node := cur.(type) {
+		case map[string]any:
+			if seg.isIdx {
+				return unavailable(), fmt.Errorf("json_path: index [%d] applied to an object", seg.index)
+			}
+			v, ok := node[seg.key]
+			if !ok {
+				return unavailable(), fmt.Errorf("json_path: key %q not found", seg.key)
+			}
+			cur = v
+		case []any:
+			if !seg.isIdx {
+				return unavailable(), fmt.Errorf("json_path: key %q applied to an array", seg.key)
+			}
+			if seg.index >= len(node) {
+				return unavailable(), fmt.Errorf("json_path: index [%d] out of range", seg.index)
+			}
+			cur = node[seg.index]
+		default:
+			return unavailable(), errors.New("json_path: path continues past a scalar value")
+		}
+	}
+
+	switch v := cur.(type) {
+	case nil:
+		return unavailable(), nil
+	case json.Number:
+		return parseValue(v.String()), nil
+	case string:
+		return parseValue(v), nil
+	case bool:
+		return boolean(v), nil
+	default:
+		return unavailable(), errors.New("json_path: selects an object or array, not a value")
+	}
+}
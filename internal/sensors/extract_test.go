@@ -0,0 +1,148 @@
+package sensors
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Reading
+	}{
+		{"42", number(42, 0)},
+		{" 42\n", number(42, 0)},
+		{"-3.14", number(-3.14, 2)},
+		{"0.500", number(0.5, 3)},
+		{"1.5e-3", number(0.0015, 4)},
+		{"1.2345e+10", number(1.2345e10, 0)},
+		{"2E2", number(200, 0)},
+		{"1e-20", number(1e-20, 10)},
+		{"ONLINE", text("ONLINE")},
+		{"12 MB", text("12 MB")},
+		{"", unavailable()},
+		{" \t\n", unavailable()},
+	}
+	for _, tt := range tests {
+		if got := parseValue(tt.in); got != tt.want {
+			t.Errorf("parseValue(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	key := func(k string) jsonPathSegment { return jsonPathSegment{key: k} }
+	idx := func(i int) jsonPathSegment { return jsonPathSegment{index: i, isIdx: true} }
+
+	tests := []struct {
+		in      string
+		want    []jsonPathSegment
+		wantErr bool
+	}{
+		{in: "status", want: []jsonPathSegment{key("status")}},
+		{in: "$.status", want: []jsonPathSegment{key("status")}},
+		{in: "$status", want: []jsonPathSegment{key("status")}},
+		{in: "pools[0].health", want: []jsonPathSegment{key("pools"), idx(0), key("health")}},
+		{in: "matrix[1][2]", want: []jsonPathSegment{key("matrix"), idx(1), idx(2)}},
+		{in: "[3].name", want: []jsonPathSegment{idx(3), key("name")}},
+		{in: "a.b.c", want: []jsonPathSegment{key("a"), key("b"), key("c")}},
+		{in: "", wantErr: true},
+		{in: "$", wantErr: true},
+		{in: "a..b", wantErr: true},
+		{in: "a[0", wantErr: true},
+		{in: "a[x]", wantErr: true},
+		{in: "a[-1]", wantErr: true},
+		{in: "a[0]b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseJSONPath(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueExtractor(t *testing.T) {
+	doc := `{"pools":[{"name":"tank","health":"ONLINE","cap":0.75,"ok":true,"err":null}],"count":3}`
+
+	tests := []struct {
+		name     string
+		regex    string
+		jsonPath string
+		field    int
+		in       string
+		want     Reading
+		wantErr  bool
+	}{
+		{name: "whole output", in: " 17.5\n", want: number(17.5, 1)},
+		{name: "regex group", regex: `(\d+)%`, in: "cap 83% used", want: number(83, 0)},
+		{name: "regex match", regex: `\d+`, in: "load 4 of 8", want: number(4, 0)},
+		{name: "regex no match", regex: `(\d+)%`, in: "n/a", wantErr: true},
+		{name: "field", field: 2, in: "tank 83 ONLINE", want: number(83, 0)},
+		{name: "field out of range", field: 4, in: "tank 83 ONLINE", wantErr: true},
+		{name: "json string", jsonPath: "pools[0].health", in: doc, want: text("ONLINE")},
+		{name: "json number", jsonPath: "pools[0].cap", in: doc, want: number(0.75, 2)},
+		{name: "json integer", jsonPath: "count", in: doc, want: number(3, 0)},
+		{name: "json bool", jsonPath: "pools[0].ok", in: doc, want: boolean(true)},
+		{name: "json null", jsonPath: "pools[0].err", in: doc, want: unavailable()},
+		{name: "json object", jsonPath: "pools[0]", in: doc, wantErr: true},
+		{name: "json missing key", jsonPath: "pools[0].size", in: doc, wantErr: true},
+		{name: "json index out of range", jsonPath: "pools[1].name", in: doc, wantErr: true},
+		{name: "json key on array", jsonPath: "pools.name", in: doc, wantErr: true},
+		{name: "json index on object", jsonPath: "count[0]", in: doc, wantErr: true},
+		{name: "json invalid", jsonPath: "count", in: "not json", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := newValueExtractor(tt.regex, tt.jsonPath, tt.field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := e.extract([]byte(tt.in))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewValueExtractorErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		regex    string
+		jsonPath string
+		field    int
+	}{
+		{name: "two selectors", regex: `\d+`, field: 1},
+		{name: "invalid regex", regex: `(`},
+		{name: "two groups", regex: `(\d+) (\d+)`},
+		{name: "invalid path", jsonPath: "a[0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newValueExtractor(tt.regex, tt.jsonPath, tt.field); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/keys"
+)
+
+// fileReadLimit bounds how much of a file is read on each collection;
+// values of interest are small sysfs/procfs files.
+const fileReadLimit = 64 << 10
+
+type fileSensor struct {
+	base
+	path      string
+	scale     float64
+	extractor *valueExtractor
+}
+
+// newFileSensors creates one sensor for a plain path, or one sensor per file
+// matching a glob pattern, keyed by the parts matched by the wildcards.
+func newFileSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("path is required")
+	}
+	if !filepath.IsAbs(cfg.Path) {
+		return nil, fmt.Errorf("path must be absolute (got: %s)", cfg.Path)
+	}
+
+	extractor, err := newValueExtractor(cfg.Regex, cfg.JSONPath, cfg.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := cfg.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	if !strings.ContainsAny(cfg.Path, "*?[") {
+		return []Sensor{&fileSensor{
+			base:      base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+			path:      cfg.Path,
+			scale:     scale,
+			extractor: extractor,
+		}}, nil
+	}
+
+	matches, err := filepath.Glob(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("path pattern %q is invalid: %w", cfg.Path, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no file matches path %s", cfg.Path)
+	}
+
+	out := make([]Sensor, 0, len(matches))
+	for _, m := range matches {
+		id := globMatchID(cfg.Path, m)
+
+		out = append(out, &fileSensor{
+			base: base{
+				key:      key + "_" + keys.Sanitize(id),
+				name:     fmt.Sprintf("%s %s", cfg.Name, id),
+				interval: cfg.Interval,
+				ha:       cfg.HA,
+			},
+			path:      m,
+			scale:     scale,
+			extractor: extractor,
+		})
+	}
+	return out, nil
+}
+
+// globMatchID returns the path elements of match that correspond to
+// wildcard elements of pattern, e.g. "thermal_zone0" for
+// /sys/class/thermal/thermal_zone*/temp.
+func globMatchID(pattern, match string) string {
+	pp := strings.Split(pattern, "/")
+	mp := strings.Split(match, "/")
+
+	var parts []string
+	for i := range min(len(pp), len(mp)) {
+		if strings.ContainsAny(pp[i], "*?[") {
+			parts = append(parts, mp[i])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s *fileSensor) Collect(ctx context.Context) (Reading, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return unavailable(), fmt.Errorf("file(%s): %w", s.path, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, fileReadLimit))
+	if err != nil {
+		return unavailable(), fmt.Errorf("file(%s): %w", s.path, err)
+	}
+
+	r, err := s.extractor.extract(data)
+	if err != nil {
+		return unavailable(), fmt.Errorf("file(%s): %w", s.path, err)
+	}
+
+	if s.scale != 1 && r.Kind == ReadingNumber {
+		r.Value *= s.scale
+		// Keep the resolution of the raw value, e.g. millidegrees scaled
+		// by 0.001 keep three decimals.
+		if shift := -math.Floor(math.Log10(math.Abs(s.scale))); shift > 0 {
+			r.Precision = min(r.Precision+int(shift), 10)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,95 @@
+package sensors
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestFileSensorsGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "thermal_zone0", "temp"), "45123\n")
+	writeFile(t, filepath.Join(dir, "thermal_zone1", "temp"), "38000\n")
+	writeFile(t, filepath.Join(dir, "cooling_device0", "temp"), "1\n")
+
+	got, err := newFileSensors("thermal", config.SensorConfig{
+		Name:     "Thermal",
+		Interval: time.Minute,
+		Path:     filepath.Join(dir, "thermal_zone*", "temp"),
+		Scale:    0.001,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		key, name string
+		r         Reading
+	}{
+		{key: "thermal_thermal_zone0", name: "Thermal thermal_zone0", r: number(45.123, 3)},
+		{key: "thermal_thermal_zone1", name: "Thermal thermal_zone1", r: number(38, 3)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d sensors, want %d", len(got), len(want))
+	}
+	for i, s := range got {
+		if s.Key() != want[i].key || s.Name() != want[i].name {
+			t.Errorf("sensor %d = %s %q, want %s %q", i, s.Key(), s.Name(), want[i].key, want[i].name)
+		}
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		if r.Kind != ReadingNumber || r.Precision != want[i].r.Precision || math.Abs(r.Value-want[i].r.Value) > 1e-9 {
+			t.Errorf("%s = %+v, want %+v", s.Key(), r, want[i].r)
+		}
+	}
+}
+
+func TestFileSensorField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loadavg")
+	writeFile(t, path, "0.52 0.58 0.59 1/467 12345\n")
+
+	got, err := newFileSensors("load", config.SensorConfig{Interval: time.Minute, Path: path, Field: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := got[0].Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := number(0.58, 2); r != want {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+}
+
+func TestFileSensorMissingFile(t *testing.T) {
+	// A plain path may appear later; until then the sensor is unavailable.
+	path := filepath.Join(t.TempDir(), "missing")
+	got, err := newFileSensors("f", config.SensorConfig{Interval: time.Minute, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := got[0].Collect(context.Background()); err == nil || r.Available() {
+		t.Errorf("got %+v, %v; want unavailable with an error", r, err)
+	}
+}
+
+func TestFileSensorsErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, cfg := range []config.SensorConfig{
+		{},
+		{Path: "relative/temp"},
+		{Path: filepath.Join(dir, "none*", "temp")},
+		{Path: filepath.Join(dir, "[", "temp")},
+	} {
+		cfg.Interval = time.Minute
+		if _, err := newFileSensors("f", cfg); err == nil {
+			t.Errorf("path %q: expected an error", cfg.Path)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package sensors
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// drmBackend reads GPU metrics exposed by kernel DRM drivers under
+// /sys/class/drm/card*/device.
+type drmBackend struct {
+	backendName string
+	drivers     []string
+}
+
+var (
+	amdgpuBackend gpuBackend = drmBackend{backendName: "amdgpu", drivers: []string{"amdgpu"}}
+	i915Backend   gpuBackend = drmBackend{backendName: "i915", drivers: []string{"i915", "xe"}}
+	drmGPUBackend gpuBackend = drmBackend{backendName: "drm", drivers: []string{"amdgpu", "i915", "xe"}}
+)
+
+var drmCardRe = regexp.MustCompile(`^card(\d+)$`)
+
+type drmCard struct {
+	index  int
+	dir    string
+	pci    string
+	driver string
+}
+
+func (b drmBackend) name() string { return b.backendName }
+
+func (b drmBackend) devices() ([]gpuDevice, error) {
+	cards, err := b.cards()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]gpuDevice, 0, len(cards))
+	for _, c := range cards {
+		out = append(out, gpuDevice{Index: c.index, ID: c.pci, Name: drmCardName(c)})
+	}
+	return out, nil
+}
+
+// resolve accepts a card index ("1"), a card name ("card1")
+// or a PCI address ("0000:03:00.0").
+func (b drmBackend) resolve(selector string) (gpuDevice, error) {
+	cards, err := b.cards()
+	if err != nil {
+		return gpuDevice{}, err
+	}
+
+	for _, c := range cards {
+		if selector == strconv.Itoa(c.index) || selector == "card"+strconv.Itoa(c.index) || selector == c.pci {
+			return gpuDevice{Index: c.index, ID: c.pci, Name: drmCardName(c)}, nil
+		}
+	}
+
+	return gpuDevice{}, fmt.Errorf("%s: gpu %s not found", b.backendName, selector)
+}
+
+func (b drmBackend) query(device string, metrics map[gpuMetric]struct{}, state *gpuQueryState) map[gpuMetric]gpuValue {
+	cards, err := b.cards()
+	if err != nil {
+		return failGPUMetrics(metrics, err)
+	}
+
+	idx := slices.IndexFunc(cards, func(c drmCard) bool { return c.pci == device })
+	if idx < 0 {
+		return failGPUMetrics(metrics, fmt.Errorf("%s: gpu %s not found", b.backendName, device))
+	}
+	card := cards[idx]
+
+	out := make(map[gpuMetric]gpuValue, len(metrics))
+	for m := range metrics {
+		switch m {
+		case gpuMetricUsage:
+			out[m] = drmUsage(card)
+		case gpuMetricMemoryUsage:
+			out[m] = drmMemoryUsage(card)
+		case gpuMetricTemp:
+			out[m] = drmTemp(card)
+		case gpuMetricPower:
+			out[m] = drmPower(card, state)
+		default:
+			out[m] = gpuValue{err: errors.New("unknown metric")}
+		}
+	}
+
+	return out
+}
+
+func (b drmBackend) cards() ([]drmCard, error) {
+	matches, err := filepath.Glob(sysfsPath("class", "drm", "card*"))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]drmCard, 0, len(matches))
+	for _, path := range matches {
+		m := drmCardRe.FindStringSubmatch(filepath.Base(path))
+		if m == nil {
+			// Connectors such as card0-DP-1 share the prefix.
+			continue
+		}
+
+		dir := filepath.Join(path, "device")
+
+		driver, err := linkBase(filepath.Join(dir, "driver"))
+		if err != nil || !slices.Contains(b.drivers, driver) {
+			continue
+		}
+
+		pci, err := linkBase(dir)
+		if err != nil {
+			continue
+		}
+
+		index, _ := strconv.Atoi(m[1])
+		out = append(out, drmCard{index: index, dir: dir, pci: pci, driver: driver})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].index < out[j].index })
+	return out, nil
+}
+
+func drmCardName(c drmCard) string {
+	if name, err := readSysfsString(filepath.Join(c.dir, "product_name")); err == nil && name != "" {
+		return name
+	}
+	if c.driver == "amdgpu" {
+		return "AMD GPU"
+	}
+	return "Intel GPU"
+}
+
+func drmUsage(c drmCard) gpuValue {
+	v, err := readSysfsUint(filepath.Join(c.dir, "gpu_busy_percent"))
+	if err != nil {
+		return gpuValue{err: drmNotSupported(c, "utilization", err)}
+	}
+	return gpuValue{value: float64(v)}
+}
+
+func drmMemoryUsage(c drmCard) gpuValue {
+	used, err := readSysfsUint(filepath.Join(c.dir, "mem_info_vram_used"))
+	if err != nil {
+		return gpuValue{err: drmNotSupported(c, "memory", err)}
+	}
+	total, err := readSysfsUint(filepath.Join(c.dir, "mem_info_vram_total"))
+	if err != nil {
+		return gpuValue{err: drmNotSupported(c, "memory", err)}
+	}
+	if total == 0 {
+		return gpuValue{value: 0}
+	}
+	return gpuValue{value: float64(used) / float64(total) * 100.0}
+}
+
+func drmTemp(c drmCard) gpuValue {
+	hwmon, err := drmHwmonDir(c)
+	if err != nil {
+		return gpuValue{err: drmNotSupported(c, "temperature", err)}
+	}
+
+	// amdgpu exposes edge, junction and mem sensors; edge matches what
+	// vendor tools report as the GPU temperature.
+	input := filepath.Join(hwmon, "temp1_input")
+	labels, _ := filepath.Glob(filepath.Join(hwmon, "temp*_label"))
+	for _, l := range labels {
+		if label, err := readSysfsString(l); err == nil && label == "edge" {
+			input = strings.TrimSuffix(l, "_label") + "_input"
+			break
+		}
+	}
+
+	milliC, err := readSysfsInt(input)
+	if err != nil {
+		return gpuValue{err: drmNotSupported(c, "temperature", err)}
+	}
+	return gpuValue{value: float64(milliC) / 1000.0}
+}
+
+func drmPower(c drmCard, state *gpuQueryState) gpuValue {
+	hwmon, err := drmHwmonDir(c)
+	if err != nil {
+		return gpuValue{err: drmNotSupported(c, "power", err)}
+	}
+
+	for _, f := range []string{"power1_average", "power1_input"} {
+		if microW, err := readSysfsUint(filepath.Join(hwmon, f)); err == nil {
+			return gpuValue{value: float64(microW) / 1e6}
+		}
+	}
+
+	// Intel discrete GPUs only expose a cumulative energy counter.
+	microJ, err := readSysfsUint(filepath.Join(hwmon, "energy1_input"))
+	if err != nil {
+		return gpuValue{err: drmNotSupported(c, "power", err)}
+	}
+
+	now := time.Now()
+	prevJ, prevAt := state.energyMicroJ, state.energyAt
+	state.energyMicroJ, state.energyAt = microJ, now
+
+	if prevAt.IsZero() || microJ < prevJ {
+		return gpuValue{pending: true}
+	}
+
+	elapsed := now.Sub(prevAt).Seconds()
+	if elapsed <= 0 {
+		return gpuValue{pending: true}
+	}
+	return gpuValue{value: float64(microJ-prevJ) / 1e6 / elapsed}
+}
+
+func drmHwmonDir(c drmCard) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(c.dir, "hwmon", "hwmon*"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", errors.New("no hwmon directory")
+	}
+	sort.Strings(matches)
+	return matches[0], nil
+}
+
+func drmNotSupported(c drmCard, metric string, err error) error {
+	return fmt.Errorf("%s %s not available on %s: %w", c.driver, metric, c.pci, err)
+}
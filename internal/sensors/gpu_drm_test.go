@@ -0,0 +1,172 @@
+package sensors
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeDRMCard adds /sys/class/drm/<card> for a PCI device bound to driver
+// and returns the device directory.
+func writeDRMCard(t *testing.T, root, card, pci, driver string) string {
+	t.Helper()
+
+	dev := filepath.Join(root, "devices", "pci0000:00", pci)
+	drv := filepath.Join(root, "bus", "pci", "drivers", driver)
+	for _, dir := range []string{dev, drv, filepath.Join(root, "class", "drm", card)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(drv, filepath.Join(dev, "driver")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dev, filepath.Join(root, "class", "drm", card, "device")); err != nil {
+		t.Fatal(err)
+	}
+	return dev
+}
+
+func fakeDRM(t *testing.T) (amd, intel string) {
+	root := useFakeSysfs(t)
+
+	amd = writeDRMCard(t, root, "card0", "0000:03:00.0", "amdgpu")
+	writeFile(t, filepath.Join(amd, "product_name"), "Radeon RX 6800\n")
+	writeFile(t, filepath.Join(amd, "gpu_busy_percent"), "37\n")
+	writeFile(t, filepath.Join(amd, "mem_info_vram_used"), "4294967296\n")
+	writeFile(t, filepath.Join(amd, "mem_info_vram_total"), "17179869184\n")
+	hw := filepath.Join(amd, "hwmon", "hwmon3")
+	writeFile(t, filepath.Join(hw, "temp1_input"), "71000\n")
+	writeFile(t, filepath.Join(hw, "temp1_label"), "junction\n")
+	writeFile(t, filepath.Join(hw, "temp2_input"), "64000\n")
+	writeFile(t, filepath.Join(hw, "temp2_label"), "edge\n")
+	writeFile(t, filepath.Join(hw, "power1_average"), "152000000\n")
+
+	// Connectors share the card prefix and are not cards.
+	if err := os.MkdirAll(filepath.Join(root, "class", "drm", "card0-DP-1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	intel = writeDRMCard(t, root, "card1", "0000:04:00.0", "i915")
+	writeFile(t, filepath.Join(intel, "hwmon", "hwmon5", "temp1_input"), "48500\n")
+	writeFile(t, filepath.Join(intel, "hwmon", "hwmon5", "energy1_input"), "1000000\n")
+
+	// Cards of other drivers are skipped.
+	writeDRMCard(t, root, "card2", "0000:05:00.0", "nouveau")
+	return amd, intel
+}
+
+func TestDRMCards(t *testing.T) {
+	fakeDRM(t)
+
+	tests := []struct {
+		backend drmBackend
+		want    []gpuDevice
+	}{
+		{
+			backend: amdgpuBackend.(drmBackend),
+			want:    []gpuDevice{{Index: 0, ID: "0000:03:00.0", Name: "Radeon RX 6800"}},
+		},
+		{
+			backend: i915Backend.(drmBackend),
+			want:    []gpuDevice{{Index: 1, ID: "0000:04:00.0", Name: "Intel GPU"}},
+		},
+		{
+			backend: drmGPUBackend.(drmBackend),
+			want: []gpuDevice{
+				{Index: 0, ID: "0000:03:00.0", Name: "Radeon RX 6800"},
+				{Index: 1, ID: "0000:04:00.0", Name: "Intel GPU"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backend.name(), func(t *testing.T) {
+			got, err := tt.backend.devices()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	b := drmGPUBackend.(drmBackend)
+	for _, sel := range []string{"1", "card1", "0000:04:00.0"} {
+		d, err := b.resolve(sel)
+		if err != nil || d.ID != "0000:04:00.0" {
+			t.Errorf("resolve(%q) = %+v, %v", sel, d, err)
+		}
+	}
+	if _, err := b.resolve("2"); err == nil {
+		t.Error("resolve(2): expected an error for a nouveau card")
+	}
+}
+
+func TestDRMQuery(t *testing.T) {
+	fakeDRM(t)
+
+	all := map[gpuMetric]struct{}{
+		gpuMetricUsage: {}, gpuMetricMemoryUsage: {}, gpuMetricTemp: {}, gpuMetricPower: {},
+	}
+	got := amdgpuBackend.query("0000:03:00.0", all, &gpuQueryState{})
+
+	want := map[gpuMetric]float64{
+		gpuMetricUsage:       37,
+		gpuMetricMemoryUsage: 25,
+		// The edge sensor, not temp1.
+		gpuMetricTemp:  64,
+		gpuMetricPower: 152,
+	}
+	for m, w := range want {
+		if v := got[m]; v.err != nil || v.pending || v.value != w {
+			t.Errorf("%s = %+v, want %g", gpuMetricNames[m], v, w)
+		}
+	}
+
+	intel := i915Backend.query("0000:04:00.0", all, &gpuQueryState{})
+	if v := intel[gpuMetricTemp]; v.err != nil || v.value != 48.5 {
+		t.Errorf("i915 temperature = %+v, want 48.5", v)
+	}
+	for _, m := range []gpuMetric{gpuMetricUsage, gpuMetricMemoryUsage} {
+		if v := intel[m]; v.err == nil {
+			t.Errorf("i915 %s = %+v, want a not available error", gpuMetricNames[m], v)
+		}
+	}
+
+	if v := amdgpuBackend.query("0000:09:00.0", all, &gpuQueryState{})[gpuMetricTemp]; v.err == nil {
+		t.Error("unknown card: expected an error")
+	}
+}
+
+func TestDRMPowerFromEnergy(t *testing.T) {
+	_, intel := fakeDRM(t)
+	energy := filepath.Join(intel, "hwmon", "hwmon5", "energy1_input")
+	card := drmCard{index: 1, dir: intel, pci: "0000:04:00.0", driver: "i915"}
+	state := &gpuQueryState{}
+
+	// The first sample only sets the baseline.
+	if v := drmPower(card, state); !v.pending {
+		t.Fatalf("first sample = %+v, want pending", v)
+	}
+	if state.energyMicroJ != 1000000 {
+		t.Fatalf("baseline = %d µJ, want 1000000", state.energyMicroJ)
+	}
+
+	// 30 J over one second.
+	state.energyAt = time.Now().Add(-time.Second)
+	writeFile(t, energy, "31000000\n")
+	v := drmPower(card, state)
+	if v.err != nil || v.pending || math.Abs(v.value-30) > 0.5 {
+		t.Errorf("power = %+v, want about 30 W", v)
+	}
+
+	// A counter going backwards (driver reload) restarts the baseline.
+	writeFile(t, energy, "500\n")
+	if v := drmPower(card, state); !v.pending {
+		t.Errorf("after reset = %+v, want pending", v)
+	}
+}
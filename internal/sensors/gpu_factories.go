@@ -3,133 +3,220 @@ package sensors
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/Miklakapi/gometrum/internal/config"
-	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/Miklakapi/gometrum/internal/keys"
 )
 
-type gpuUsageSensor struct {
-	base
+type gpuMetric uint8
+
+const (
+	gpuMetricUsage gpuMetric = iota
+	gpuMetricMemoryUsage
+	gpuMetricTemp
+	gpuMetricPower
+)
+
+var gpuMetricNames = map[gpuMetric]string{
+	gpuMetricUsage:       "utilization",
+	gpuMetricMemoryUsage: "memory",
+	gpuMetricTemp:        "temperature",
+	gpuMetricPower:       "power",
 }
 
-func newGPUUsageSensor(key string, cfg config.SensorConfig) Sensor {
-	return &gpuUsageSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
-	}
+type gpuDevice struct {
+	Index int
+	// ID is stable across reboots: the UUID for NVML, the PCI address for DRM.
+	ID   string
+	Name string
 }
 
-func (s *gpuUsageSensor) Collect(ctx context.Context) (string, error) {
-	st, err := nvmlStats()
-	if err != nil {
-		return "unavailable", err
-	}
-	return fmt.Sprintf("%d", st.UtilGPU), nil
+type gpuValue struct {
+	value float64
+	err   error
+	// pending is set while a delta-based metric only has its first sample.
+	pending bool
 }
 
-type gpuMemoryUsageSensor struct {
-	base
+// gpuQueryState is kept per snapshot cache for backends that derive
+// a metric from counter deltas between two snapshots.
+type gpuQueryState struct {
+	energyMicroJ uint64
+	energyAt     time.Time
 }
 
-func newGPUMemoryUsageSensor(key string, cfg config.SensorConfig) Sensor {
-	return &gpuMemoryUsageSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
-	}
+type gpuBackend interface {
+	name() string
+	devices() ([]gpuDevice, error)
+	resolve(selector string) (gpuDevice, error)
+	query(device string, metrics map[gpuMetric]struct{}, state *gpuQueryState) map[gpuMetric]gpuValue
 }
 
-func (s *gpuMemoryUsageSensor) Collect(ctx context.Context) (string, error) {
-	st, err := nvmlStats()
-	if err != nil {
-		return "unavailable", err
+func gpuBackends(name string, session *nvmlSession) ([]gpuBackend, error) {
+	nvmlGPUBackend := nvmlBackend{session: session}
+
+	switch name {
+	case "nvml":
+		return []gpuBackend{nvmlGPUBackend}, nil
+	case "amdgpu":
+		return []gpuBackend{amdgpuBackend}, nil
+	case "i915":
+		return []gpuBackend{i915Backend}, nil
+	case "", "auto":
+		return []gpuBackend{nvmlGPUBackend, drmGPUBackend}, nil
+	default:
+		return nil, fmt.Errorf("backend must be one of: nvml, amdgpu, i915, auto (got: %s)", name)
 	}
-	if st.MemTotal == 0 {
-		return "0", nil
-	}
-	percent := (float64(st.MemUsed) / float64(st.MemTotal)) * 100.0
-	return fmt.Sprintf("%.1f", percent), nil
 }
 
-type gpuTempSensor struct {
+type gpuSensor struct {
 	base
+	metric   gpuMetric
+	snapshot *gpuSnapshotCache
 }
 
-func newGPUTempSensor(key string, cfg config.SensorConfig) Sensor {
-	return &gpuTempSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
-	}
-}
-
-func (s *gpuTempSensor) Collect(ctx context.Context) (string, error) {
-	st, err := nvmlStats()
+func newGPUSensors(key string, cfg config.SensorConfig, metric gpuMetric, res *resources) ([]Sensor, error) {
+	backends, err := gpuBackends(cfg.Backend, res.nvml)
 	if err != nil {
-		return "unavailable", err
+		return nil, err
 	}
-	return fmt.Sprintf("%d", st.TempC), nil
-}
 
-type gpuPowerSensor struct {
-	base
-}
+	if len(cfg.GPUs) == 0 {
+		var (
+			backend gpuBackend
+			devices []gpuDevice
+		)
+		for _, b := range backends {
+			if list, err := b.devices(); err == nil && len(list) > 0 {
+				backend, devices = b, list
+				break
+			}
+		}
+
+		if len(devices) <= 1 {
+			// Single GPU (or driver not ready yet): keep the plain key so the
+			// entity does not change compared to single-GPU setups.
+			device := "0"
+			if len(devices) == 1 {
+				device = devices[0].ID
+			} else {
+				backend = backends[0]
+			}
+
+			return []Sensor{&gpuSensor{
+				base:     base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+				metric:   metric,
+				snapshot: sharedGPUSnapshot(res, backend, device, cfg.Interval, metric),
+			}}, nil
+		}
+
+		out := make([]Sensor, 0, len(devices))
+		for _, d := range devices {
+			sKey := key + "_" + keys.Sanitize(d.ID)
+			sName := fmt.Sprintf("%s %d (%s)", cfg.Name, d.Index, d.Name)
+
+			out = append(out, &gpuSensor{
+				base:     base{key: sKey, name: sName, interval: cfg.Interval, ha: cfg.HA},
+				metric:   metric,
+				snapshot: sharedGPUSnapshot(res, backend, d.ID, cfg.Interval, metric),
+			})
+		}
+		return out, nil
+	}
 
-func newGPUPowerSensor(key string, cfg config.SensorConfig) Sensor {
-	return &gpuPowerSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+	out := make([]Sensor, 0, len(cfg.GPUs))
+	for _, sel := range cfg.GPUs {
+		// Keys derive from the configured selector only, so they stay
+		// the same whether or not the driver is available at startup.
+		sKey := key + "_" + keys.Sanitize(sel)
+		sName := fmt.Sprintf("%s %s", cfg.Name, sel)
+		backend, device := backends[0], sel
+
+		for _, b := range backends {
+			if d, err := b.resolve(sel); err == nil {
+				sName = fmt.Sprintf("%s %d (%s)", cfg.Name, d.Index, d.Name)
+				backend, device = b, d.ID
+				break
+			}
+		}
+
+		out = append(out, &gpuSensor{
+			base:     base{key: sKey, name: sName, interval: cfg.Interval, ha: cfg.HA},
+			metric:   metric,
+			snapshot: sharedGPUSnapshot(res, backend, device, cfg.Interval, metric),
+		})
 	}
+
+	return out, nil
 }
 
-func (s *gpuPowerSensor) Collect(ctx context.Context) (string, error) {
-	st, err := nvmlStats()
-	if err != nil {
-		return "unavailable", err
+func (s *gpuSensor) Collect(ctx context.Context) (Reading, error) {
+	v := s.snapshot.get(ctx, s.metric)
+	if v.err != nil {
+		return unavailable(), fmt.Errorf("gpu(%s %s): %w", s.snapshot.backend.name(), s.snapshot.device, v.err)
+	}
+	if v.pending {
+		return unavailable(), nil
 	}
-	watts := float64(st.PowerMilliW) / 1000.0
-	return fmt.Sprintf("%.1f", watts), nil
-}
 
-type gpuStats struct {
-	UtilGPU     uint32
-	MemUsed     uint64
-	MemTotal    uint64
-	TempC       uint32
-	PowerMilliW uint32
+	switch s.metric {
+	case gpuMetricUsage, gpuMetricTemp:
+		return number(v.value, 0), nil
+	case gpuMetricMemoryUsage, gpuMetricPower:
+		return number(v.value, 1), nil
+	default:
+		return unavailable(), fmt.Errorf("gpu: unknown metric")
+	}
 }
 
-func nvmlStats() (gpuStats, error) {
-	var st gpuStats
+// gpuSnapshotCache queries all metrics used by sensors of one GPU in one pass
+// and shares the result between sensors collected in the same tick.
+type gpuSnapshotCache struct {
+	backend gpuBackend
+	device  string
+	// metrics are registered while sensors are built, before the first query.
+	metrics map[gpuMetric]struct{}
+	state   gpuQueryState
 
-	if ret := nvml.Init(); ret != nvml.SUCCESS {
-		return st, fmt.Errorf("nvml init failed: %s", nvml.ErrorString(ret))
-	}
-	defer nvml.Shutdown()
+	values *tickCache[map[gpuMetric]gpuValue]
+}
 
-	dev, ret := nvml.DeviceGetHandleByIndex(0)
-	if ret != nvml.SUCCESS {
-		return st, fmt.Errorf("nvml get device failed: %s", nvml.ErrorString(ret))
-	}
+type gpuSnapshotKey struct {
+	backend  string
+	device   string
+	interval time.Duration
+}
 
-	util, ret := dev.GetUtilizationRates()
-	if ret != nvml.SUCCESS {
-		return st, fmt.Errorf("nvml utilization failed: %s", nvml.ErrorString(ret))
+// sharedGPUSnapshot returns the cache for a device within one interval group.
+func sharedGPUSnapshot(res *resources, backend gpuBackend, device string, interval time.Duration, metric gpuMetric) *gpuSnapshotCache {
+	k := gpuSnapshotKey{backend: backend.name(), device: device, interval: interval}
+	c, ok := res.gpuSnapshots[k]
+	if !ok {
+		c = &gpuSnapshotCache{
+			backend: backend,
+			device:  device,
+			metrics: make(map[gpuMetric]struct{}),
+		}
+		c.values = newTickCache(interval, func(context.Context) (map[gpuMetric]gpuValue, error) {
+			return c.backend.query(c.device, c.metrics, &c.state), nil
+		})
+		res.gpuSnapshots[k] = c
 	}
+	c.metrics[metric] = struct{}{}
 
-	mem, ret := dev.GetMemoryInfo()
-	if ret != nvml.SUCCESS {
-		return st, fmt.Errorf("nvml memory failed: %s", nvml.ErrorString(ret))
-	}
+	return c
+}
 
-	temp, ret := dev.GetTemperature(nvml.TEMPERATURE_GPU)
-	if ret != nvml.SUCCESS {
-		return st, fmt.Errorf("nvml temperature failed: %s", nvml.ErrorString(ret))
-	}
+func (c *gpuSnapshotCache) get(ctx context.Context, metric gpuMetric) gpuValue {
+	values, _, _ := c.values.get(ctx)
+	return values[metric]
+}
 
-	power, ret := dev.GetPowerUsage()
-	if ret != nvml.SUCCESS {
-		return st, fmt.Errorf("nvml power failed: %s", nvml.ErrorString(ret))
+func failGPUMetrics(metrics map[gpuMetric]struct{}, err error) map[gpuMetric]gpuValue {
+	out := make(map[gpuMetric]gpuValue, len(metrics))
+	for m := range metrics {
+		out[m] = gpuValue{err: err}
 	}
-
-	st.UtilGPU = util.Gpu
-	st.MemUsed = mem.Used
-	st.MemTotal = mem.Total
-	st.TempC = temp
-	st.PowerMilliW = power
-	return st, nil
+	return out
 }
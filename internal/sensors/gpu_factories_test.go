@@ -0,0 +1,95 @@
+package sensors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+// fakeGPUBackend counts queries and reports a fixed value for each metric.
+type fakeGPUBackend struct {
+	queries int
+}
+
+func (b *fakeGPUBackend) name() string                      { return "fake" }
+func (b *fakeGPUBackend) devices() ([]gpuDevice, error)     { return nil, nil }
+func (b *fakeGPUBackend) resolve(string) (gpuDevice, error) { return gpuDevice{}, nil }
+
+func (b *fakeGPUBackend) query(_ string, metrics map[gpuMetric]struct{}, _ *gpuQueryState) map[gpuMetric]gpuValue {
+	b.queries++
+	out := make(map[gpuMetric]gpuValue, len(metrics))
+	for m := range metrics {
+		out[m] = gpuValue{value: float64(m) + 10}
+	}
+	return out
+}
+
+func TestGPUSnapshotShared(t *testing.T) {
+	backend := &fakeGPUBackend{}
+	res := newResources()
+
+	usage := &gpuSensor{metric: gpuMetricUsage, snapshot: sharedGPUSnapshot(res, backend, "0", time.Minute, gpuMetricUsage)}
+	temp := &gpuSensor{metric: gpuMetricTemp, snapshot: sharedGPUSnapshot(res, backend, "0", time.Minute, gpuMetricTemp)}
+	if usage.snapshot != temp.snapshot {
+		t.Fatal("sensors of one GPU and interval do not share a snapshot")
+	}
+
+	for _, s := range []*gpuSensor{usage, temp} {
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := number(float64(s.metric)+10, 0); r != want {
+			t.Errorf("metric %d = %+v, want %+v", s.metric, r, want)
+		}
+	}
+	if backend.queries != 1 {
+		t.Errorf("got %d queries, want 1 per tick", backend.queries)
+	}
+
+	// Another interval group and another Build query on their own.
+	if sharedGPUSnapshot(res, backend, "0", time.Second, gpuMetricUsage) == usage.snapshot {
+		t.Error("snapshot shared across intervals")
+	}
+	if sharedGPUSnapshot(newResources(), backend, "0", time.Minute, gpuMetricUsage) == usage.snapshot {
+		t.Error("snapshot shared across builds")
+	}
+}
+
+func TestGPUSensorKeysFromSelectors(t *testing.T) {
+	// Without a driver the selectors do not resolve; keys and names
+	// still derive from them so entities do not change once it loads.
+	got, err := newGPUSensors("gpu_usage", config.SensorConfig{
+		Name:     "GPU usage",
+		Interval: time.Minute,
+		Backend:  "nvml",
+		GPUs:     []string{"0", "GPU-8d5e0c2a", "00000000:65:00.0"},
+	}, gpuMetricUsage, newResources())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"gpu_usage_0":                "GPU usage 0",
+		"gpu_usage_gpu_8d5e0c2a":     "GPU usage GPU-8d5e0c2a",
+		"gpu_usage_00000000_65_00_0": "GPU usage 00000000:65:00.0",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d sensors, want %d", len(got), len(want))
+	}
+	for _, s := range got {
+		if want[s.Key()] != s.Name() {
+			t.Errorf("%s = %q, want %q", s.Key(), s.Name(), want[s.Key()])
+		}
+	}
+}
+
+func TestIsGPUIndex(t *testing.T) {
+	for s, want := range map[string]bool{"0": true, "12": true, "": false, "-1": false, "GPU-1": false, "0:1": false} {
+		if got := isGPUIndex(s); got != want {
+			t.Errorf("isGPUIndex(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
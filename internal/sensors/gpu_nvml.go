@@ -0,0 +1,198 @@
+package sensors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// nvmlSession is initialized on first use and kept open until the sensors
+// are closed. A failed init is retried on the next use, so a driver loaded
+// after the agent started is picked up without a restart.
+type nvmlSession struct {
+	mu          sync.Mutex
+	initialized bool
+}
+
+func (s *nvmlSession) init() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.initialized {
+		return nil
+	}
+	if ret := nvml.Init(); ret != nvml.SUCCESS {
+		return fmt.Errorf("nvml init failed: %s", nvml.ErrorString(ret))
+	}
+	s.initialized = true
+	return nil
+}
+
+func (s *nvmlSession) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialized {
+		return
+	}
+	nvml.Shutdown()
+	s.initialized = false
+}
+
+type nvmlBackend struct {
+	session *nvmlSession
+}
+
+func (nvmlBackend) name() string { return "nvml" }
+
+func (b nvmlBackend) devices() ([]gpuDevice, error) {
+	return listNVMLDevices(b.session)
+}
+
+func (b nvmlBackend) resolve(selector string) (gpuDevice, error) {
+	return resolveNVMLDevice(b.session, selector)
+}
+
+func (b nvmlBackend) query(device string, metrics map[gpuMetric]struct{}, _ *gpuQueryState) map[gpuMetric]gpuValue {
+	if err := b.session.init(); err != nil {
+		return failGPUMetrics(metrics, err)
+	}
+
+	dev, err := nvmlDeviceBySelector(device)
+	if err != nil {
+		return failGPUMetrics(metrics, err)
+	}
+
+	out := make(map[gpuMetric]gpuValue, len(metrics))
+	for m := range metrics {
+		v, ret := nvmlQuery(dev, m)
+		if ret != nvml.SUCCESS {
+			out[m] = gpuValue{err: fmt.Errorf("nvml %s failed: %s", gpuMetricNames[m], nvml.ErrorString(ret))}
+			continue
+		}
+		out[m] = gpuValue{value: v}
+	}
+
+	return out
+}
+
+func nvmlQuery(dev nvml.Device, metric gpuMetric) (float64, nvml.Return) {
+	switch metric {
+	case gpuMetricUsage:
+		util, ret := dev.GetUtilizationRates()
+		return float64(util.Gpu), ret
+	case gpuMetricMemoryUsage:
+		mem, ret := dev.GetMemoryInfo()
+		if ret != nvml.SUCCESS || mem.Total == 0 {
+			return 0, ret
+		}
+		return float64(mem.Used) / float64(mem.Total) * 100.0, ret
+	case gpuMetricTemp:
+		temp, ret := dev.GetTemperature(nvml.TEMPERATURE_GPU)
+		return float64(temp), ret
+	case gpuMetricPower:
+		power, ret := dev.GetPowerUsage()
+		return float64(power) / 1000.0, ret
+	default:
+		return 0, nvml.ERROR_NOT_SUPPORTED
+	}
+}
+
+func listNVMLDevices(session *nvmlSession) ([]gpuDevice, error) {
+	if err := session.init(); err != nil {
+		return nil, err
+	}
+
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("nvml device count failed: %s", nvml.ErrorString(ret))
+	}
+
+	out := make([]gpuDevice, 0, count)
+	for i := 0; i < count; i++ {
+		dev, ret := nvml.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("nvml get device %d failed: %s", i, nvml.ErrorString(ret))
+		}
+
+		d, err := nvmlDeviceInfo(dev)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+
+	return out, nil
+}
+
+func resolveNVMLDevice(session *nvmlSession, selector string) (gpuDevice, error) {
+	if err := session.init(); err != nil {
+		return gpuDevice{}, err
+	}
+
+	dev, err := nvmlDeviceBySelector(selector)
+	if err != nil {
+		return gpuDevice{}, err
+	}
+	return nvmlDeviceInfo(dev)
+}
+
+func nvmlDeviceInfo(dev nvml.Device) (gpuDevice, error) {
+	index, ret := dev.GetIndex()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, fmt.Errorf("nvml index failed: %s", nvml.ErrorString(ret))
+	}
+
+	uuid, ret := dev.GetUUID()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, fmt.Errorf("nvml uuid failed: %s", nvml.ErrorString(ret))
+	}
+
+	name, ret := dev.GetName()
+	if ret != nvml.SUCCESS {
+		name = "GPU"
+	}
+
+	return gpuDevice{Index: index, ID: uuid, Name: name}, nil
+}
+
+// nvmlDeviceBySelector accepts a device index ("1"), a UUID ("GPU-...")
+// or a PCI bus id ("00000000:65:00.0"). NVML must be initialized.
+func nvmlDeviceBySelector(selector string) (nvml.Device, error) {
+	var (
+		dev nvml.Device
+		ret nvml.Return
+	)
+
+	switch {
+	case isGPUIndex(selector):
+		idx, _ := strconv.Atoi(selector)
+		dev, ret = nvml.DeviceGetHandleByIndex(idx)
+	case strings.HasPrefix(selector, "GPU-") || strings.HasPrefix(selector, "MIG-"):
+		dev, ret = nvml.DeviceGetHandleByUUID(selector)
+	case strings.Contains(selector, ":"):
+		dev, ret = nvml.DeviceGetHandleByPciBusId(selector)
+	default:
+		return nil, fmt.Errorf("invalid gpu selector %q (expected index, UUID or PCI bus id)", selector)
+	}
+
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("nvml get device %s failed: %s", selector, nvml.ErrorString(ret))
+	}
+	return dev, nil
+}
+
+func isGPUIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
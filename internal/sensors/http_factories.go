@@ -0,0 +1,173 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/keys"
+)
+
+// httpReadLimit bounds how much of a response body is read;
+// status endpoints return small JSON or text documents.
+const httpReadLimit = 1 << 20
+
+type httpSensor struct {
+	base
+	url       string
+	body      *tickCache[[]byte]
+	extractor *valueExtractor
+}
+
+// newHTTPSensors creates one sensor for the whole response, or one sensor
+// per entry of values, all sharing a single request per tick.
+func newHTTPSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("url is required")
+	}
+
+	body := newTickCache(cfg.Interval, newHTTPRequest(cfg, httpReadLimit).do)
+
+	if len(cfg.Values) == 0 {
+		extractor, err := newValueExtractor(cfg.Regex, cfg.JSONPath, cfg.Field)
+		if err != nil {
+			return nil, err
+		}
+		return []Sensor{&httpSensor{
+			base:      base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+			url:       cfg.URL,
+			body:      body,
+			extractor: extractor,
+		}}, nil
+	}
+
+	if cfg.Regex != "" || cfg.JSONPath != "" || cfg.Field > 0 {
+		return nil, errors.New("regex, json_path and field must be set per value when values are used")
+	}
+
+	names := slices.Sorted(maps.Keys(cfg.Values))
+
+	out := make([]Sensor, 0, len(names))
+	for _, name := range names {
+		v := cfg.Values[name]
+
+		suffix := keys.Sanitize(name)
+		if suffix == "" {
+			return nil, fmt.Errorf("values.%s: key must contain letters or digits", name)
+		}
+
+		extractor, err := newValueExtractor(v.Regex, v.JSONPath, v.Field)
+		if err != nil {
+			return nil, fmt.Errorf("values.%s: %w", name, err)
+		}
+
+		sName := v.Name
+		if sName == "" {
+			sName = fmt.Sprintf("%s %s", cfg.Name, name)
+		}
+
+		out = append(out, &httpSensor{
+			base: base{
+				key:      key + "_" + suffix,
+				name:     sName,
+				interval: cfg.Interval,
+				ha:       haWithOverrides(cfg.HA, v.HA),
+			},
+			url:       cfg.URL,
+			body:      body,
+			extractor: extractor,
+		})
+	}
+	return out, nil
+}
+
+func (s *httpSensor) Collect(ctx context.Context) (Reading, error) {
+	body, _, err := s.body.get(ctx)
+	if err != nil {
+		return unavailable(), fmt.Errorf("http(%s): %w", s.url, err)
+	}
+
+	r, err := s.extractor.extract(body)
+	if err != nil {
+		return unavailable(), fmt.Errorf("http(%s): %w", s.url, err)
+	}
+	return r, nil
+}
+
+// httpRequest is a GET request configured by url, headers, timeout
+// and accepted statuses. Bodies longer than limit bytes are rejected.
+type httpRequest struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+	accept  []int
+	limit   int64
+}
+
+func newHTTPRequest(cfg config.SensorConfig, limit int64) *httpRequest {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	timeout = min(timeout, cfg.Interval)
+
+	return &httpRequest{
+		client:  &http.Client{Timeout: timeout},
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		accept:  cfg.AcceptStatus,
+		limit:   limit,
+	}
+}
+
+// do returns the response body. Statuses other than the accepted ones
+// (2xx by default) are errors, which makes the sensors unavailable.
+func (r *httpRequest) do(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.headers {
+		// The client sends req.Host and ignores a Host header.
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !r.accepts(resp.StatusCode) {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	// One byte over the limit tells a truncated body from one of exactly
+	// limit bytes; values must not be taken from a cut-off document.
+	data, err := io.ReadAll(io.LimitReader(resp.Body, r.limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body failed: %w", err)
+	}
+	if int64(len(data)) > r.limit {
+		return nil, fmt.Errorf("response exceeds %d bytes", r.limit)
+	}
+	return data, nil
+}
+
+func (r *httpRequest) accepts(code int) bool {
+	if len(r.accept) == 0 {
+		return code >= 200 && code <= 299
+	}
+	return slices.Contains(r.accept, code)
+}
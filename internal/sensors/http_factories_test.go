@@ -0,0 +1,133 @@
+package sensors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestHTTPSensorsShareOneRequest(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"status":"ok","queue":{"depth":12},"healthy":true}`))
+	}))
+	defer srv.Close()
+
+	got, err := newHTTPSensors("app", config.SensorConfig{
+		Name:     "App",
+		Interval: time.Minute,
+		URL:      srv.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Values: map[string]config.SensorValueConfig{
+			"status":  {JSONPath: "status"},
+			"queue":   {JSONPath: "queue.depth"},
+			"healthy": {JSONPath: "healthy"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Reading{
+		"app_healthy": boolean(true),
+		"app_queue":   number(12, 0),
+		"app_status":  text("ok"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d sensors, want %d", len(got), len(want))
+	}
+	for _, s := range got {
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		if r != want[s.Key()] {
+			t.Errorf("%s = %+v, want %+v", s.Key(), r, want[s.Key()])
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestHTTPRequestErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/exact":
+			w.Write([]byte(strings.Repeat("a", 16)))
+		case "/large":
+			w.Write([]byte(strings.Repeat("a", 17)))
+		case "/missing":
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path: "/exact"},
+		{path: "/large", wantErr: "response exceeds 16 bytes"},
+		{path: "/missing", wantErr: "unexpected status 404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := newHTTPRequest(config.SensorConfig{Interval: time.Minute, URL: srv.URL + tt.path}, 16)
+			body, err := req.do(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(body) != 16 {
+					t.Errorf("got %d bytes, want 16", len(body))
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPRequestHostAndAcceptStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "status.local" {
+			http.Error(w, "unknown host "+r.Host, http.StatusMisdirectedRequest)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"healthy":false}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.SensorConfig{
+		Interval: time.Minute,
+		URL:      srv.URL,
+		Headers:  map[string]string{"Host": "status.local"},
+	}
+	if _, err := newHTTPRequest(cfg, 16).do(context.Background()); err == nil || err.Error() != "unexpected status 503 Service Unavailable" {
+		t.Errorf("got error %v, want the 503 rejected by default", err)
+	}
+
+	cfg.AcceptStatus = []int{200, 503}
+	body, err := newHTTPRequest(cfg, 64).do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"healthy":false}` {
+		t.Errorf("got body %q", body)
+	}
+}
@@ -0,0 +1,165 @@
+package sensors
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hwmonTemp is one temperature input of a hwmon chip.
+type hwmonTemp struct {
+	chip   string
+	device string
+	label  string
+	input  string
+}
+
+// hwmonFilter selects temperature inputs by chip name and label.
+// Both accept shell patterns (e.g. "Core *") and are case-insensitive.
+type hwmonFilter struct {
+	chip  string
+	label string
+}
+
+func (f hwmonFilter) match(t hwmonTemp) bool {
+	return hwmonMatch(f.chip, t.chip) && hwmonMatch(f.label, t.label)
+}
+
+func hwmonMatch(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && ok
+}
+
+func validateHwmonPattern(field, pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("%s pattern %q is invalid: %w", field, pattern, err)
+	}
+	return nil
+}
+
+func listHwmonTemps() ([]hwmonTemp, error) {
+	dirs, err := filepath.Glob(sysfsPath("class", "hwmon", "hwmon*"))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]hwmonTemp, 0)
+	for _, dir := range dirs {
+		chip, err := readSysfsString(filepath.Join(dir, "name"))
+		if err != nil {
+			continue
+		}
+
+		// The device link tells apart chips sharing a name,
+		// e.g. two NVMe drives or coretemp on dual-socket boards.
+		device, _ := linkBase(filepath.Join(dir, "device"))
+
+		inputs, err := filepath.Glob(filepath.Join(dir, "temp*_input"))
+		if err != nil {
+			continue
+		}
+
+		for _, input := range inputs {
+			id := strings.TrimSuffix(filepath.Base(input), "_input")
+
+			label, err := readSysfsString(filepath.Join(dir, id+"_label"))
+			if err != nil || label == "" {
+				label = id
+			}
+
+			out = append(out, hwmonTemp{chip: chip, device: device, label: label, input: input})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].chip != out[j].chip {
+			return out[i].chip < out[j].chip
+		}
+		if out[i].device != out[j].device {
+			return out[i].device < out[j].device
+		}
+		return naturalLess(out[i].label, out[j].label)
+	})
+
+	return out, nil
+}
+
+func readHwmonTemp(t hwmonTemp) (float64, error) {
+	milliC, err := readSysfsInt(t.input)
+	if err != nil {
+		return 0, fmt.Errorf("%s %s: %w", t.chip, t.label, err)
+	}
+	return float64(milliC) / 1000.0, nil
+}
+
+// aggregateTemps combines the readings of all matching inputs.
+// Inputs that fail to read are skipped as long as one of them succeeds.
+func aggregateTemps(temps []hwmonTemp, mode string) (float64, error) {
+	var (
+		sum   float64
+		max   float64
+		count int
+		errs  []error
+	)
+
+	for _, t := range temps {
+		v, err := readHwmonTemp(t)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if count == 0 || v > max {
+			max = v
+		}
+		sum += v
+		count++
+	}
+
+	if count == 0 {
+		if len(errs) == 0 {
+			return 0, errors.New("no matching temperature inputs")
+		}
+		return 0, errors.Join(errs...)
+	}
+
+	if mode == "avg" {
+		return sum / float64(count), nil
+	}
+	return max, nil
+}
+
+// naturalLess orders labels like "Core 2" before "Core 10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			if len(da) != len(db) {
+				return len(da) < len(db)
+			}
+			if da != db {
+				return da < db
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,173 @@
+package sensors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+// writeHwmon adds a hwmon chip with temperature inputs in millidegrees,
+// labeled when labels has an entry for the input index. A non-empty device
+// links the chip to a device directory of that name.
+func writeHwmon(t *testing.T, root, hwmon, chip, device string, milliC []string, labels map[int]string) {
+	t.Helper()
+
+	dir := filepath.Join(root, "class", "hwmon", hwmon)
+	writeFile(t, filepath.Join(dir, "name"), chip+"\n")
+	for i, v := range milliC {
+		id := fmt.Sprintf("temp%d", i+1)
+		writeFile(t, filepath.Join(dir, id+"_input"), v+"\n")
+		if l, ok := labels[i]; ok {
+			writeFile(t, filepath.Join(dir, id+"_label"), l+"\n")
+		}
+	}
+
+	if device != "" {
+		target := filepath.Join(root, "devices", device)
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(dir, "device")); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func fakeHwmon(t *testing.T) {
+	root := useFakeSysfs(t)
+	writeHwmon(t, root, "hwmon0", "k10temp", "0000:00:18.3", []string{"55250"}, map[int]string{0: "Tctl"})
+	writeHwmon(t, root, "hwmon1", "coretemp", "coretemp.0",
+		[]string{"60000", "40000", "50000", "45000"},
+		map[int]string{0: "Package id 0", 1: "Core 10", 2: "Core 2", 3: "Core 0"})
+	writeHwmon(t, root, "hwmon2", "nvme", "nvme0", []string{"38850"}, map[int]string{0: "Composite"})
+	writeHwmon(t, root, "hwmon3", "nvme", "nvme1", []string{"41850"}, map[int]string{0: "Composite"})
+	// Unlabeled inputs are named after the input.
+	writeHwmon(t, root, "hwmon4", "acpitz", "", []string{"27800", "29800"}, nil)
+}
+
+func TestCPUTempHwmon(t *testing.T) {
+	fakeHwmon(t)
+
+	tests := []struct {
+		name      string
+		chip      string
+		label     string
+		aggregate string
+		want      Reading
+		wantErr   bool
+	}{
+		{name: "chip and label", chip: "k10temp", label: "tctl", want: number(55.25, 1)},
+		{name: "max by default", chip: "coretemp", label: "Core *", want: number(50, 1)},
+		{name: "avg", chip: "coretemp", label: "Core *", aggregate: "avg", want: number(45, 1)},
+		{name: "label only", label: "Package id 0", want: number(60, 1)},
+		{name: "no match", chip: "zenpower", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newCPUTempSensor("cpu_temp", config.SensorConfig{
+				Interval:  time.Minute,
+				Chip:      tt.chip,
+				Label:     tt.label,
+				Aggregate: tt.aggregate,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.Collect(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTempOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.SensorConfig
+	}{
+		{name: "aggregate without chip or label", cfg: config.SensorConfig{Aggregate: "max"}},
+		{name: "unknown aggregate", cfg: config.SensorConfig{Chip: "coretemp", Aggregate: "sum"}},
+		{name: "invalid chip pattern", cfg: config.SensorConfig{Chip: "[core"}},
+		{name: "invalid label pattern", cfg: config.SensorConfig{Label: "Core [0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Interval = time.Minute
+			if _, err := newCPUTempSensor("cpu_temp", tt.cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := newTemperatureSensors("temps", config.SensorConfig{Chip: "coretemp", Aggregate: "max"}); err == nil {
+		t.Error("temperature: expected an error for aggregate")
+	}
+}
+
+func TestTemperatureSensors(t *testing.T) {
+	fakeHwmon(t)
+
+	got, err := newTemperatureSensors("temps", config.SensorConfig{Name: "Temp", Interval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	readings := make(map[string]Reading)
+	for _, s := range got {
+		keys = append(keys, s.Key())
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		readings[s.Key()] = r
+	}
+
+	// Sorted by chip, device and label in natural order; the two nvme
+	// chips are told apart by their device.
+	want := []string{
+		"temps_acpitz_temp1",
+		"temps_acpitz_temp2",
+		"temps_coretemp_core_0",
+		"temps_coretemp_core_2",
+		"temps_coretemp_core_10",
+		"temps_coretemp_package_id_0",
+		"temps_k10temp_tctl",
+		"temps_nvme_nvme0_composite",
+		"temps_nvme_nvme1_composite",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got keys %v, want %v", keys, want)
+	}
+	if r := readings["temps_nvme_nvme1_composite"]; r != number(41.85, 1) {
+		t.Errorf("nvme1 = %+v", r)
+	}
+	if r := readings["temps_coretemp_core_10"]; r != number(40, 1) {
+		t.Errorf("core 10 = %+v", r)
+	}
+
+	only, err := newTemperatureSensors("temps", config.SensorConfig{Interval: time.Minute, Chip: "nvme", Label: "composite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(only) != 2 {
+		t.Errorf("got %d nvme sensors, want 2", len(only))
+	}
+}
@@ -13,18 +13,35 @@ type memoryUsageSensor struct {
 }
 
 func newMemoryUsageSensor(key string, cfg config.SensorConfig) Sensor {
-	return &memoryUsageSensor{
+	s := &memoryUsageSensor{
 		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
 	}
+	if cfg.Attributes {
+		return withAttributes{s}
+	}
+	return s
+}
+
+func (s *memoryUsageSensor) Collect(ctx context.Context) (Reading, error) {
+	r, _, err := s.collect(ctx)
+	return r, err
 }
 
-func (s *memoryUsageSensor) Collect(ctx context.Context) (string, error) {
-	vm, err := mem.VirtualMemory()
+// collect also returns memory sizes in bytes as attributes.
+func (s *memoryUsageSensor) collect(ctx context.Context) (Reading, map[string]any, error) {
+	vm, err := mem.VirtualMemoryWithContext(ctx)
 	if err != nil {
-		return "unavailable", fmt.Errorf("memory_usage: %w", err)
+		return unavailable(), nil, fmt.Errorf("memory_usage: %w", err)
 	}
 
-	return fmt.Sprintf("%.1f", vm.UsedPercent), nil
+	attrs := map[string]any{
+		"total_bytes":     vm.Total,
+		"used_bytes":      vm.Used,
+		"available_bytes": vm.Available,
+		"cached_bytes":    vm.Cached,
+		"buffers_bytes":   vm.Buffers,
+	}
+	return number(vm.UsedPercent, 1), attrs, nil
 }
 
 type swapUsageSensor struct {
@@ -32,20 +49,38 @@ type swapUsageSensor struct {
 }
 
 func newSwapUsageSensor(key string, cfg config.SensorConfig) Sensor {
-	return &swapUsageSensor{
+	s := &swapUsageSensor{
 		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
 	}
+	if cfg.Attributes {
+		return withAttributes{s}
+	}
+	return s
 }
 
-func (s *swapUsageSensor) Collect(ctx context.Context) (string, error) {
-	sm, err := mem.SwapMemory()
+func (s *swapUsageSensor) Collect(ctx context.Context) (Reading, error) {
+	r, _, err := s.collect(ctx)
+	return r, err
+}
+
+// collect also returns swap sizes in bytes as attributes.
+func (s *swapUsageSensor) collect(ctx context.Context) (Reading, map[string]any, error) {
+	sm, err := mem.SwapMemoryWithContext(ctx)
 	if err != nil {
-		return "unavailable", fmt.Errorf("swap_usage: %w", err)
+		return unavailable(), nil, fmt.Errorf("swap_usage: %w", err)
 	}
 
-	if sm.Total == 0 {
-		return "0.0", nil
+	attrs := map[string]any{
+		"total_bytes": sm.Total,
+		"used_bytes":  sm.Used,
+		"free_bytes":  sm.Free,
 	}
+	return swapPercent(sm), attrs, nil
+}
 
-	return fmt.Sprintf("%.1f", sm.UsedPercent), nil
+func swapPercent(sm *mem.SwapMemoryStat) Reading {
+	if sm.Total == 0 {
+		return number(0, 1)
+	}
+	return number(sm.UsedPercent, 1)
 }
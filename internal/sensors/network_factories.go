@@ -1,142 +1,547 @@
 package sensors
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
 	"net"
 	"os"
-	"os/exec"
-	"strconv"
+	"path"
+	"sort"
 	"strings"
 
 	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/keys"
+	gnet "github.com/shirou/gopsutil/v4/net"
 )
 
 type hostIPSensor struct {
 	base
+	interfaces       []string
+	family           string
+	excludeLinkLocal bool
+	excludeVirtual   bool
 }
 
-func newHostIPSensor(key string, cfg config.SensorConfig) Sensor {
-	return &hostIPSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+type hostAddress struct {
+	Interface string `json:"interface"`
+	Address   string `json:"address"`
+	Family    string `json:"family"`
+}
+
+func newHostIPSensor(key string, cfg config.SensorConfig) (Sensor, error) {
+	switch cfg.Family {
+	case "", "ipv4", "ipv6", "both":
+	default:
+		return nil, fmt.Errorf("family must be one of: ipv4, ipv6, both (got: %s)", cfg.Family)
+	}
+
+	for _, pattern := range cfg.IncludeInterfaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("include_interfaces pattern %q is invalid: %w", pattern, err)
+		}
+	}
+
+	family := cfg.Family
+	if family == "" {
+		family = "ipv4"
 	}
+
+	s := &hostIPSensor{
+		base:             base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+		interfaces:       cfg.IncludeInterfaces,
+		family:           family,
+		excludeLinkLocal: cfg.ExcludeLinkLocal,
+		excludeVirtual:   cfg.ExcludeVirtual,
+	}
+
+	if cfg.Attributes {
+		return withAttributes{s}, nil
+	}
+	return s, nil
 }
 
-func (s *hostIPSensor) Collect(ctx context.Context) (string, error) {
-	addrs, err := net.InterfaceAddrs()
+func (s *hostIPSensor) Collect(ctx context.Context) (Reading, error) {
+	r, _, err := s.collect(ctx)
+	return r, err
+}
+
+// collect also returns every matching address, and the interface of the
+// published one, as attributes.
+func (s *hostIPSensor) collect(ctx context.Context) (Reading, map[string]any, error) {
+	addrs, err := s.addresses()
 	if err != nil {
-		return "unavailable", fmt.Errorf("host_ip: %w", err)
+		return unavailable(), nil, err
 	}
 
-	for _, addr := range addrs {
-		ipNet, ok := addr.(*net.IPNet)
-		if !ok {
-			continue
+	val, err := s.pick(addrs)
+
+	attrs := map[string]any{"addresses": addrs}
+	for _, a := range addrs {
+		if a.Address == val.Text {
+			attrs["interface"] = a.Interface
+			break
 		}
+	}
+
+	return val, attrs, err
+}
+
+// pick returns the first IPv4 address, or the first IPv6 address
+// when only IPv6 is requested or no IPv4 address is present.
+func (s *hostIPSensor) pick(addrs []hostAddress) (Reading, error) {
+	for _, family := range []string{"ipv4", "ipv6"} {
+		for _, a := range addrs {
+			if a.Family == family {
+				return text(a.Address), nil
+			}
+		}
+	}
+
+	return unavailable(), fmt.Errorf("host_ip: no matching %s address found", strings.Replace(s.family, "both", "IPv4/IPv6", 1))
+}
 
-		ip := ipNet.IP
-		if ip == nil || ip.IsLoopback() {
+// addresses lists matching addresses ordered by include_interfaces
+// (earlier patterns first), then by interface index.
+func (s *hostIPSensor) addresses() ([]hostAddress, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("host_ip: %w", err)
+	}
+
+	type candidate struct {
+		iface    net.Interface
+		priority int
+	}
+
+	candidates := make([]candidate, 0, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if s.excludeVirtual && isVirtualInterface(iface.Name) {
 			continue
 		}
 
-		ip4 := ip.To4()
-		if ip4 == nil {
+		priority := 0
+		if len(s.interfaces) > 0 {
+			priority = -1
+			for i, pattern := range s.interfaces {
+				if ok, _ := path.Match(pattern, iface.Name); ok {
+					priority = i
+					break
+				}
+			}
+			if priority < 0 {
+				continue
+			}
+		}
+
+		candidates = append(candidates, candidate{iface: iface, priority: priority})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].priority < candidates[j].priority
+	})
+
+	out := make([]hostAddress, 0)
+	for _, c := range candidates {
+		addrs, err := c.iface.Addrs()
+		if err != nil {
 			continue
 		}
 
-		return ip4.String(), nil
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP == nil || ipNet.IP.IsLoopback() {
+				continue
+			}
+
+			ip := ipNet.IP
+			if s.excludeLinkLocal && ip.IsLinkLocalUnicast() {
+				continue
+			}
+
+			family := "ipv6"
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, family = ip4, "ipv4"
+			}
+			if s.family != "both" && s.family != family {
+				continue
+			}
+
+			out = append(out, hostAddress{Interface: c.iface.Name, Address: ip.String(), Family: family})
+		}
 	}
 
-	return "unavailable", fmt.Errorf("host_ip: no non-loopback IPv4 found")
+	return out, nil
+}
+
+// isVirtualInterface reports interfaces without a backing device,
+// such as bridges, veth pairs, tunnels and VPNs.
+func isVirtualInterface(name string) bool {
+	_, err := os.Stat(sysfsPath("devices", "virtual", "net", name))
+	return err == nil
 }
 
-type wiFiSignalSensor struct {
+// wifiLink is the state of one station-mode wireless interface as reported
+// by nl80211.
+type wifiLink struct {
+	Interface    string `json:"interface"`
+	Connected    bool   `json:"connected"`
+	SSID         string `json:"ssid,omitempty"`
+	BSSID        string `json:"bssid,omitempty"`
+	FrequencyMHz int    `json:"frequency,omitempty"`
+	Channel      int    `json:"channel,omitempty"`
+	SignalDBm    int    `json:"signal,omitempty"`
+	// TxBitrate and RxBitrate are in Mbit/s.
+	TxBitrate float64 `json:"tx_bitrate,omitempty"`
+	RxBitrate float64 `json:"rx_bitrate,omitempty"`
+}
+
+type wifiField uint8
+
+const (
+	wifiFieldSignal wifiField = iota
+	wifiFieldSSID
+	wifiFieldBSSID
+	wifiFieldFrequency
+	wifiFieldTxBitrate
+	wifiFieldRxBitrate
+)
+
+const (
+	wifiBitrateTx = "tx"
+	wifiBitrateRx = "rx"
+)
+
+var wifiBitrateMetrics = []string{wifiBitrateTx, wifiBitrateRx}
+
+type wifiSensor struct {
 	base
+	iface string
+	field wifiField
 }
 
-func newWiFiSignalSensor(key string, cfg config.SensorConfig) Sensor {
-	return &wiFiSignalSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+func newWiFiSensor(key string, cfg config.SensorConfig, field wifiField) Sensor {
+	s := &wifiSensor{
+		base:  base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+		iface: cfg.Interface,
+		field: field,
 	}
+
+	if cfg.Attributes {
+		return withAttributes{s}
+	}
+	return s
 }
 
-func (s *wiFiSignalSensor) Collect(ctx context.Context) (string, error) {
-	_, level, err := readWiFiLevelFromProc()
+func newWiFiBitrateSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	metrics, err := selectMetrics(cfg.Metrics, wifiBitrateMetrics)
 	if err != nil {
-		return "unavailable", err
+		return nil, err
 	}
 
-	if level > 0 {
-		level = -level
+	out := make([]Sensor, 0, len(metrics))
+	for _, m := range metrics {
+		field := wifiFieldTxBitrate
+		if m == wifiBitrateRx {
+			field = wifiFieldRxBitrate
+		}
+
+		mCfg := cfg
+		mCfg.Name = fmt.Sprintf("%s %s", cfg.Name, strings.ToUpper(m))
+		out = append(out, newWiFiSensor(key+"_"+m, mCfg, field))
 	}
-	return strconv.Itoa(level), nil
+	return out, nil
 }
 
-type wiFiSSIDSensor struct {
-	base
+func (s *wifiSensor) Collect(ctx context.Context) (Reading, error) {
+	r, _, err := s.collect(ctx)
+	return r, err
 }
 
-func newWiFiSSIDSensor(key string, cfg config.SensorConfig) Sensor {
-	return &wiFiSSIDSensor{
-		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+// collect also returns the whole link state (SSID, BSSID, channel, signal,
+// bitrates) as attributes.
+func (s *wifiSensor) collect(ctx context.Context) (Reading, map[string]any, error) {
+	link, err := s.link()
+	if err != nil {
+		return unavailable(), nil, err
 	}
+
+	val, err := s.value(link)
+	return val, map[string]any{"link": link}, err
 }
 
-func (s *wiFiSSIDSensor) Collect(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "iwgetid", "-r")
-	out, err := cmd.Output()
-	if err != nil {
-		return "unavailable", err
+func (s *wifiSensor) value(link wifiLink) (Reading, error) {
+	if !link.Connected {
+		return unavailable(), nil
 	}
 
-	ssid := strings.TrimSpace(string(out))
-	if ssid == "" {
-		return "unavailable", nil
+	switch s.field {
+	case wifiFieldSignal:
+		return number(float64(link.SignalDBm), 0), nil
+	case wifiFieldSSID:
+		if link.SSID == "" {
+			return unavailable(), nil
+		}
+		return text(link.SSID), nil
+	case wifiFieldBSSID:
+		return text(link.BSSID), nil
+	case wifiFieldFrequency:
+		if link.FrequencyMHz == 0 {
+			return unavailable(), nil
+		}
+		return number(float64(link.FrequencyMHz), 0), nil
+	case wifiFieldTxBitrate:
+		return number(link.TxBitrate, 1), nil
+	case wifiFieldRxBitrate:
+		return number(link.RxBitrate, 1), nil
+	default:
+		return unavailable(), fmt.Errorf("wifi: unknown field")
 	}
-	return ssid, nil
 }
 
-func readWiFiLevelFromProc() (iface string, level int, err error) {
-	data, err := os.ReadFile("/proc/net/wireless")
+// link returns the configured interface, or the first connected one
+// when no interface is set.
+func (s *wifiSensor) link() (wifiLink, error) {
+	links, err := readWiFiLinks()
 	if err != nil {
-		return "", 0, fmt.Errorf("wifi_signal: read /proc/net/wireless failed: %w", err)
+		return wifiLink{}, fmt.Errorf("wifi: %w", err)
 	}
 
-	sc := bufio.NewScanner(bytes.NewReader(data))
-	lineNo := 0
-	for sc.Scan() {
-		lineNo++
-		if lineNo <= 2 {
-			continue
+	if s.iface != "" {
+		for _, l := range links {
+			if l.Interface == s.iface {
+				return l, nil
+			}
 		}
+		return wifiLink{}, fmt.Errorf("wifi(%s): not a wireless interface", s.iface)
+	}
 
-		line := strings.TrimSpace(sc.Text())
-		if line == "" {
-			continue
+	if len(links) == 0 {
+		return wifiLink{}, fmt.Errorf("wifi: no wireless interface")
+	}
+	for _, l := range links {
+		if l.Connected {
+			return l, nil
 		}
+	}
+	return links[0], nil
+}
 
-		parts := strings.Fields(line)
-		if len(parts) < 4 {
-			continue
+// wifiChannel maps a center frequency to its IEEE 802.11 channel number.
+func wifiChannel(mhz int) int {
+	switch {
+	case mhz == 2484:
+		return 14
+	case mhz >= 2412 && mhz < 2484:
+		return (mhz - 2407) / 5
+	case mhz == 5935:
+		return 2
+	case mhz >= 5955 && mhz <= 7115:
+		return (mhz - 5950) / 5
+	case mhz >= 5000 && mhz < 5935:
+		return (mhz - 5000) / 5
+	case mhz >= 58320 && mhz <= 70200:
+		return (mhz - 56160) / 2160
+	default:
+		return 0
+	}
+}
+
+const (
+	netRxBytes   = "rx_bytes"
+	netTxBytes   = "tx_bytes"
+	netRxPackets = "rx_packets"
+	netTxPackets = "tx_packets"
+	netRxErrors  = "rx_errors"
+	netTxErrors  = "tx_errors"
+	netRxDrops   = "rx_drops"
+	netTxDrops   = "tx_drops"
+)
+
+var netThroughputMetrics = []string{
+	netRxBytes, netTxBytes,
+	netRxPackets, netTxPackets,
+	netRxErrors, netTxErrors,
+	netRxDrops, netTxDrops,
+}
+
+var netThroughputMetricNames = map[string]string{
+	netRxBytes:   "download",
+	netTxBytes:   "upload",
+	netRxPackets: "RX packets",
+	netTxPackets: "TX packets",
+	netRxErrors:  "RX errors",
+	netTxErrors:  "TX errors",
+	netRxDrops:   "RX drops",
+	netTxDrops:   "TX drops",
+}
+
+// netThroughputSensor reports the rate of one interface counter between two
+// consecutive reads shared by the sensors of one configuration.
+type netThroughputSensor struct {
+	base
+	counters *tickCache[counterWindow[map[string]gnet.IOCountersStat]]
+	iface    string
+	metric   string
+}
+
+func newNetThroughputSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	metrics, err := selectMetrics(cfg.Metrics, netThroughputMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	ifaces, err := expandInterfaces(cfg.IncludeInterfaces)
+	if err != nil {
+		return nil, err
+	}
+	if len(ifaces) == 0 {
+		return nil, fmt.Errorf("no network interface matches include_interfaces")
+	}
+
+	counters := newCounterCache(cfg.Interval, true, readNetCounters)
+
+	out := make([]Sensor, 0, len(ifaces)*len(metrics))
+	for _, iface := range ifaces {
+		for _, m := range metrics {
+			sKey := key + "_" + keys.Sanitize(iface) + "_" + m
+			sName := fmt.Sprintf("%s %s %s", cfg.Name, iface, netThroughputMetricNames[m])
+
+			var ha *config.HASensorConfig
+			switch m {
+			case netRxBytes, netTxBytes:
+				ha = haWithDefaults(cfg.HA, "B/s", "data_rate")
+			case netRxErrors, netTxErrors:
+				ha = haWithDefaults(cfg.HA, "errors/s", "")
+			default:
+				ha = haWithDefaults(cfg.HA, "packets/s", "")
+			}
+
+			out = append(out, &netThroughputSensor{
+				base:     base{key: sKey, name: sName, interval: cfg.Interval, ha: ha},
+				counters: counters,
+				iface:    iface,
+				metric:   m,
+			})
 		}
+	}
+
+	return out, nil
+}
+
+// readNetCounters returns the counters of every interface keyed by name.
+func readNetCounters(ctx context.Context) (map[string]gnet.IOCountersStat, error) {
+	list, err := gnet.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
 
-		iface = strings.TrimSuffix(parts[0], ":")
-		levelStr := strings.TrimSuffix(parts[3], ".")
+	out := make(map[string]gnet.IOCountersStat, len(list))
+	for _, c := range list {
+		out[c.Name] = c
+	}
+	return out, nil
+}
 
-		f, ferr := strconv.ParseFloat(levelStr, 64)
-		if ferr != nil {
+// expandInterfaces resolves include_interfaces at startup. Plain names are
+// kept even if the interface does not exist yet (e.g. a VPN tunnel);
+// shell patterns expand to the interfaces present now. An empty list
+// selects the physical interfaces only: virtual ones such as veth pairs of
+// containers come and go, and would leave sensors for interfaces that no
+// longer exist.
+func expandInterfaces(include []string) ([]string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces failed: %w", err)
+	}
+
+	selected := make(map[string]struct{})
+	for _, pattern := range include {
+		if !strings.ContainsAny(pattern, "*?[") {
+			selected[pattern] = struct{}{}
 			continue
 		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("include_interfaces pattern %q is invalid: %w", pattern, err)
+		}
+		for _, iface := range ifaces {
+			if ok, _ := path.Match(pattern, iface.Name); ok {
+				selected[iface.Name] = struct{}{}
+			}
+		}
+	}
 
-		level = int(f)
-		return iface, level, nil
+	if len(include) == 0 {
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagLoopback == 0 && !isVirtualInterface(iface.Name) {
+				selected[iface.Name] = struct{}{}
+			}
+		}
 	}
 
-	if err := sc.Err(); err != nil {
-		return "", 0, fmt.Errorf("wifi_signal: scan /proc/net/wireless failed: %w", err)
+	out := make([]string, 0, len(selected))
+	for name := range selected {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func (s *netThroughputSensor) Collect(ctx context.Context) (Reading, error) {
+	w, _, err := s.counters.get(ctx)
+	if err != nil {
+		return unavailable(), fmt.Errorf("net_throughput(%s): %w", s.iface, err)
+	}
+
+	c, ok := w.cur[s.iface]
+	if !ok {
+		return unavailable(), fmt.Errorf("net_throughput(%s): interface not found", s.iface)
+	}
+	// A removed interface starts with fresh counters when it comes back:
+	// the window in which it reappears has no previous read for it.
+	p, ok := w.prev[s.iface]
+	if !ok {
+		return unavailable(), nil
 	}
 
-	return "", 0, fmt.Errorf("wifi_signal: no wifi interface in /proc/net/wireless")
+	// A counter going backwards (driver reload, interface recreated)
+	// leaves one window without a value.
+	cur, prev := netCounter(c, s.metric), netCounter(p, s.metric)
+	if cur < prev {
+		return unavailable(), nil
+	}
+
+	rate, ok := w.perSecond(float64(cur - prev))
+	if !ok {
+		return unavailable(), nil
+	}
+	if s.metric == netRxBytes || s.metric == netTxBytes {
+		return number(rate, 0), nil
+	}
+	return number(rate, 1), nil
+}
+
+func netCounter(c gnet.IOCountersStat, metric string) uint64 {
+	switch metric {
+	case netRxBytes:
+		return c.BytesRecv
+	case netTxBytes:
+		return c.BytesSent
+	case netRxPackets:
+		return c.PacketsRecv
+	case netTxPackets:
+		return c.PacketsSent
+	case netRxErrors:
+		return c.Errin
+	case netTxErrors:
+		return c.Errout
+	case netRxDrops:
+		return c.Dropin
+	case netTxDrops:
+		return c.Dropout
+	default:
+		return 0
+	}
 }
@@ -0,0 +1,136 @@
+package sensors
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestNetThroughputSensors(t *testing.T) {
+	proc := t.TempDir()
+	t.Setenv("HOST_PROC", proc)
+
+	writeDev := func(rxBytes, rxErrs, txBytes int) {
+		writeFile(t, filepath.Join(proc, "net", "dev"), fmt.Sprintf(
+			"Inter-|   Receive                                                |  Transmit\n"+
+				" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n"+
+				"  wg9: %d 10 %d 0 0 0 0 0 %d 10 0 0 0 0 0 0\n",
+			rxBytes, rxErrs, txBytes))
+	}
+	writeDev(1000, 0, 500)
+
+	// A plain name is kept even if the interface does not exist on the host.
+	const interval = 100 * time.Millisecond
+	got, err := newNetThroughputSensors("net", config.SensorConfig{
+		Name:              "Net",
+		Interval:          interval,
+		IncludeInterfaces: []string{"wg9"},
+		Metrics:           []string{"rx_bytes", "tx_bytes", "rx_errors"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantUnits := map[string]string{
+		"net_wg9_rx_bytes":  "B/s",
+		"net_wg9_tx_bytes":  "B/s",
+		"net_wg9_rx_errors": "errors/s",
+	}
+	if len(got) != len(wantUnits) {
+		t.Fatalf("got %d sensors, want %d", len(got), len(wantUnits))
+	}
+
+	writeDev(3000, 4, 500)
+
+	maxRate := map[string]float64{
+		"net_wg9_rx_bytes":  2000 / interval.Seconds(),
+		"net_wg9_rx_errors": 4 / interval.Seconds(),
+	}
+	for _, s := range got {
+		if want := wantUnits[s.Key()]; s.HA().Unit != want {
+			t.Errorf("%s unit = %q, want %q", s.Key(), s.HA().Unit, want)
+		}
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		if s.Key() == "net_wg9_tx_bytes" {
+			if want := number(0, 0); r != want {
+				t.Errorf("%s = %+v, want %+v", s.Key(), r, want)
+			}
+			continue
+		}
+		if r.Kind != ReadingNumber || r.Value <= 0 || r.Value > maxRate[s.Key()] {
+			t.Errorf("%s = %+v, want a rate in (0, %g]", s.Key(), r, maxRate[s.Key()])
+		}
+	}
+}
+
+func TestExpandInterfaces(t *testing.T) {
+	// Every Linux host has lo; wg9 does not exist and is kept as configured.
+	got, err := expandInterfaces([]string{"wg9", "l?", "nomatch*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"lo", "wg9"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := expandInterfaces([]string{"eth["}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestHostIPPick(t *testing.T) {
+	v4 := hostAddress{Interface: "eth0", Address: "192.168.1.10", Family: "ipv4"}
+	v6 := hostAddress{Interface: "eth0", Address: "2001:db8::10", Family: "ipv6"}
+
+	tests := []struct {
+		name    string
+		family  string
+		addrs   []hostAddress
+		want    Reading
+		wantErr string
+	}{
+		{name: "ipv4 first", family: "both", addrs: []hostAddress{v6, v4}, want: text("192.168.1.10")},
+		{name: "ipv6 fallback", family: "both", addrs: []hostAddress{v6}, want: text("2001:db8::10")},
+		{name: "none", family: "both", want: unavailable(), wantErr: "host_ip: no matching IPv4/IPv6 address found"},
+		{name: "none ipv6", family: "ipv6", want: unavailable(), wantErr: "host_ip: no matching ipv6 address found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &hostIPSensor{family: tt.family}
+			got, err := s.pick(tt.addrs)
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHostIPSensorOptions(t *testing.T) {
+	for _, cfg := range []config.SensorConfig{
+		{Family: "ipv5"},
+		{IncludeInterfaces: []string{"eth["}},
+	} {
+		if _, err := newHostIPSensor("host_ip", cfg); err == nil {
+			t.Errorf("%+v: expected an error", cfg)
+		}
+	}
+
+	s, err := newHostIPSensor("host_ip", config.SensorConfig{Interval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.(*hostIPSensor).family; got != "ipv4" {
+		t.Errorf("default family = %q, want ipv4", got)
+	}
+}
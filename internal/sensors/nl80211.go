@@ -0,0 +1,327 @@
+package sensors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	nl80211Timeout = 2 * time.Second
+
+	// nlaTypeMask strips the nested and byte-order flags from an attribute type.
+	nlaTypeMask = 0x3fff
+)
+
+var nl80211Seq atomic.Uint32
+
+// nlConn is a minimal generic netlink client, just enough for the nl80211
+// dump requests used by the Wi-Fi sensors.
+type nlConn struct {
+	fd int
+}
+
+func dialGenetlink() (*nlConn, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_GENERIC)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket failed: %w", err)
+	}
+
+	tv := unix.NsecToTimeval(nl80211Timeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("netlink timeout failed: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("netlink bind failed: %w", err)
+	}
+
+	return &nlConn{fd: fd}, nil
+}
+
+func (c *nlConn) Close() error {
+	return unix.Close(c.fd)
+}
+
+// execute sends one generic netlink request and returns the attribute
+// payloads of all replies, following multipart dumps until NLMSG_DONE.
+func (c *nlConn) execute(family uint16, cmd uint8, flags uint16, attrs []byte) ([][]byte, error) {
+	seq := nl80211Seq.Add(1)
+
+	msg := make([]byte, unix.NLMSG_HDRLEN+unix.GENL_HDRLEN, unix.NLMSG_HDRLEN+unix.GENL_HDRLEN+len(attrs))
+	binary.NativeEndian.PutUint16(msg[4:6], family)
+	binary.NativeEndian.PutUint16(msg[6:8], unix.NLM_F_REQUEST|flags)
+	binary.NativeEndian.PutUint32(msg[8:12], seq)
+	msg[unix.NLMSG_HDRLEN] = cmd
+	msg[unix.NLMSG_HDRLEN+1] = 1
+	msg = append(msg, attrs...)
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(len(msg)))
+
+	if err := unix.Sendto(c.fd, msg, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("netlink send failed: %w", err)
+	}
+
+	var (
+		out [][]byte
+		buf = make([]byte, 64*1024)
+	)
+	for {
+		n, _, err := unix.Recvfrom(c.fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("netlink receive failed: %w", err)
+		}
+
+		b := buf[:n]
+		for len(b) >= unix.NLMSG_HDRLEN {
+			l := int(binary.NativeEndian.Uint32(b[0:4]))
+			if l < unix.NLMSG_HDRLEN || l > len(b) {
+				return nil, errors.New("netlink: malformed message")
+			}
+
+			typ := binary.NativeEndian.Uint16(b[4:6])
+			mflags := binary.NativeEndian.Uint16(b[6:8])
+			mseq := binary.NativeEndian.Uint32(b[8:12])
+			body := b[unix.NLMSG_HDRLEN:l]
+			b = b[nlAlign(l):]
+
+			if mseq != seq {
+				continue
+			}
+
+			switch typ {
+			case unix.NLMSG_DONE:
+				return out, nil
+			case unix.NLMSG_ERROR:
+				if len(body) < 4 {
+					return nil, errors.New("netlink: malformed error")
+				}
+				if code := int32(binary.NativeEndian.Uint32(body[0:4])); code != 0 {
+					return nil, unix.Errno(-code)
+				}
+				return out, nil
+			}
+
+			if len(body) >= unix.GENL_HDRLEN {
+				out = append(out, body[unix.GENL_HDRLEN:])
+			}
+			if mflags&unix.NLM_F_MULTI == 0 {
+				return out, nil
+			}
+		}
+	}
+}
+
+func (c *nlConn) familyID(name string) (uint16, error) {
+	msgs, err := c.execute(unix.GENL_ID_CTRL, unix.CTRL_CMD_GETFAMILY, 0,
+		nlAttr(unix.CTRL_ATTR_FAMILY_NAME, append([]byte(name), 0)))
+	if errors.Is(err, unix.ENOENT) {
+		return 0, fmt.Errorf("%s is not available (no wireless driver loaded)", name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("resolve %s family failed: %w", name, err)
+	}
+
+	for _, m := range msgs {
+		if v, ok := parseNLAttrs(m)[unix.CTRL_ATTR_FAMILY_ID]; ok && len(v) >= 2 {
+			return binary.NativeEndian.Uint16(v), nil
+		}
+	}
+	return 0, fmt.Errorf("resolve %s family failed: no family id", name)
+}
+
+func nlAlign(n int) int {
+	return (n + unix.NLA_ALIGNTO - 1) &^ (unix.NLA_ALIGNTO - 1)
+}
+
+func nlAttr(typ uint16, value []byte) []byte {
+	b := make([]byte, nlAlign(4+len(value)))
+	binary.NativeEndian.PutUint16(b[0:2], uint16(4+len(value)))
+	binary.NativeEndian.PutUint16(b[2:4], typ)
+	copy(b[4:], value)
+	return b
+}
+
+func nlAttrUint32(typ uint16, v uint32) []byte {
+	b := make([]byte, 4)
+	binary.NativeEndian.PutUint32(b, v)
+	return nlAttr(typ, b)
+}
+
+func parseNLAttrs(b []byte) map[uint16][]byte {
+	out := make(map[uint16][]byte)
+	for len(b) >= 4 {
+		l := int(binary.NativeEndian.Uint16(b[0:2]))
+		typ := binary.NativeEndian.Uint16(b[2:4]) & nlaTypeMask
+		if l < 4 || l > len(b) {
+			break
+		}
+		out[typ] = b[4:l]
+		b = b[min(nlAlign(l), len(b)):]
+	}
+	return out
+}
+
+func nlUint32(b []byte) (uint32, bool) {
+	if len(b) < 4 {
+		return 0, false
+	}
+	return binary.NativeEndian.Uint32(b), true
+}
+
+// readWiFiLinks returns the state of every station-mode wireless interface.
+func readWiFiLinks() ([]wifiLink, error) {
+	c, err := dialGenetlink()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	family, err := c.familyID("nl80211")
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := c.execute(family, unix.NL80211_CMD_GET_INTERFACE, unix.NLM_F_DUMP, nil)
+	if err != nil {
+		return nil, fmt.Errorf("nl80211 interface dump failed: %w", err)
+	}
+
+	var links []wifiLink
+	for _, m := range msgs {
+		attrs := parseNLAttrs(m)
+
+		if t, ok := nlUint32(attrs[unix.NL80211_ATTR_IFTYPE]); !ok || t != unix.NL80211_IFTYPE_STATION {
+			continue
+		}
+		ifindex, ok := nlUint32(attrs[unix.NL80211_ATTR_IFINDEX])
+		if !ok {
+			continue
+		}
+
+		link := wifiLink{
+			Interface: string(bytes.TrimRight(attrs[unix.NL80211_ATTR_IFNAME], "\x00")),
+			SSID:      string(attrs[unix.NL80211_ATTR_SSID]),
+		}
+		if f, ok := nlUint32(attrs[unix.NL80211_ATTR_WIPHY_FREQ]); ok {
+			link.FrequencyMHz = int(f)
+		}
+
+		if err := c.readStation(family, ifindex, &link); err != nil {
+			return nil, fmt.Errorf("nl80211 station dump for %s failed: %w", link.Interface, err)
+		}
+		if link.Connected && (link.SSID == "" || link.FrequencyMHz == 0) {
+			// Older kernels do not report SSID and frequency with the
+			// interface; take them from the associated BSS instead.
+			if err := c.readBSS(family, ifindex, &link); err != nil {
+				return nil, fmt.Errorf("nl80211 scan dump for %s failed: %w", link.Interface, err)
+			}
+		}
+		link.Channel = wifiChannel(link.FrequencyMHz)
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+func (c *nlConn) readStation(family uint16, ifindex uint32, link *wifiLink) error {
+	msgs, err := c.execute(family, unix.NL80211_CMD_GET_STATION, unix.NLM_F_DUMP,
+		nlAttrUint32(unix.NL80211_ATTR_IFINDEX, ifindex))
+	if err != nil {
+		return err
+	}
+
+	// A station-mode interface has exactly one station: the access point.
+	for _, m := range msgs {
+		attrs := parseNLAttrs(m)
+		info, ok := attrs[unix.NL80211_ATTR_STA_INFO]
+		if !ok {
+			continue
+		}
+
+		link.Connected = true
+		if mac := attrs[unix.NL80211_ATTR_MAC]; len(mac) == 6 {
+			link.BSSID = net.HardwareAddr(mac).String()
+		}
+
+		sta := parseNLAttrs(info)
+		if v, ok := sta[unix.NL80211_STA_INFO_SIGNAL]; ok && len(v) >= 1 {
+			link.SignalDBm = int(int8(v[0]))
+		} else if v, ok := sta[unix.NL80211_STA_INFO_SIGNAL_AVG]; ok && len(v) >= 1 {
+			link.SignalDBm = int(int8(v[0]))
+		}
+		link.TxBitrate = nlBitrate(sta[unix.NL80211_STA_INFO_TX_BITRATE])
+		link.RxBitrate = nlBitrate(sta[unix.NL80211_STA_INFO_RX_BITRATE])
+		return nil
+	}
+
+	return nil
+}
+
+func (c *nlConn) readBSS(family uint16, ifindex uint32, link *wifiLink) error {
+	msgs, err := c.execute(family, unix.NL80211_CMD_GET_SCAN, unix.NLM_F_DUMP,
+		nlAttrUint32(unix.NL80211_ATTR_IFINDEX, ifindex))
+	if err != nil {
+		return err
+	}
+
+	for _, m := range msgs {
+		nested, ok := parseNLAttrs(m)[unix.NL80211_ATTR_BSS]
+		if !ok {
+			continue
+		}
+		bss := parseNLAttrs(nested)
+		if st, ok := nlUint32(bss[unix.NL80211_BSS_STATUS]); !ok || st != unix.NL80211_BSS_STATUS_ASSOCIATED {
+			continue
+		}
+
+		if link.FrequencyMHz == 0 {
+			if f, ok := nlUint32(bss[unix.NL80211_BSS_FREQUENCY]); ok {
+				link.FrequencyMHz = int(f)
+			}
+		}
+		if link.SSID == "" {
+			link.SSID = ssidFromIEs(bss[unix.NL80211_BSS_INFORMATION_ELEMENTS])
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// nlBitrate decodes a nested rate info attribute into Mbit/s.
+func nlBitrate(b []byte) float64 {
+	if b == nil {
+		return 0
+	}
+
+	rate := parseNLAttrs(b)
+	if v, ok := nlUint32(rate[unix.NL80211_RATE_INFO_BITRATE32]); ok {
+		return float64(v) / 10
+	}
+	if v := rate[unix.NL80211_RATE_INFO_BITRATE]; len(v) >= 2 {
+		return float64(binary.NativeEndian.Uint16(v)) / 10
+	}
+	return 0
+}
+
+func ssidFromIEs(ies []byte) string {
+	for len(ies) >= 2 {
+		id, l := ies[0], int(ies[1])
+		if 2+l > len(ies) {
+			break
+		}
+		if id == 0 {
+			return string(ies[2 : 2+l])
+		}
+		ies = ies[2+l:]
+	}
+	return ""
+}
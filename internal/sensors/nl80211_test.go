@@ -0,0 +1,115 @@
+package sensors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseNLAttrs(t *testing.T) {
+	concat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	signal := nlAttr(unix.NL80211_STA_INFO_SIGNAL, []byte{0xc4})
+	truncated := nlAttrUint32(unix.NL80211_ATTR_IFINDEX, 3)
+	binary.NativeEndian.PutUint16(truncated[0:2], 64)
+
+	tests := []struct {
+		name string
+		in   []byte
+		want map[uint16][]byte
+	}{
+		{
+			name: "padded values",
+			in: concat(
+				nlAttr(unix.NL80211_ATTR_MAC, []byte{1, 2, 3, 4, 5, 6}),
+				nlAttr(unix.NL80211_ATTR_IFINDEX, []byte{3, 0, 0, 0}),
+			),
+			want: map[uint16][]byte{
+				unix.NL80211_ATTR_MAC:     {1, 2, 3, 4, 5, 6},
+				unix.NL80211_ATTR_IFINDEX: {3, 0, 0, 0},
+			},
+		},
+		{
+			name: "nested flag is stripped",
+			in:   nlAttr(unix.NL80211_ATTR_STA_INFO|unix.NLA_F_NESTED, signal),
+			want: map[uint16][]byte{unix.NL80211_ATTR_STA_INFO: signal},
+		},
+		{
+			// The last attribute is not padded when it ends the message.
+			name: "unpadded tail",
+			in:   nlAttr(unix.NL80211_ATTR_MAC, []byte{1, 2, 3, 4, 5, 6})[:10],
+			want: map[uint16][]byte{unix.NL80211_ATTR_MAC: {1, 2, 3, 4, 5, 6}},
+		},
+		{
+			name: "length beyond buffer stops",
+			in:   concat(nlAttr(unix.NL80211_ATTR_MAC, []byte{1, 2, 3, 4, 5, 6}), truncated),
+			want: map[uint16][]byte{unix.NL80211_ATTR_MAC: {1, 2, 3, 4, 5, 6}},
+		},
+		{name: "short header", in: []byte{8, 0}, want: map[uint16][]byte{}},
+		{name: "empty", in: nil, want: map[uint16][]byte{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNLAttrs(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNLBitrate(t *testing.T) {
+	u16 := func(v uint16) []byte {
+		b := make([]byte, 2)
+		binary.NativeEndian.PutUint16(b, v)
+		return b
+	}
+
+	tests := []struct {
+		name string
+		in   []byte
+		want float64
+	}{
+		{name: "missing", in: nil, want: 0},
+		{name: "bitrate32", in: nlAttrUint32(unix.NL80211_RATE_INFO_BITRATE32, 8647), want: 864.7},
+		{name: "legacy bitrate", in: nlAttr(unix.NL80211_RATE_INFO_BITRATE, u16(540)), want: 54},
+		{
+			name: "bitrate32 preferred",
+			in: append(nlAttr(unix.NL80211_RATE_INFO_BITRATE, u16(65535)),
+				nlAttrUint32(unix.NL80211_RATE_INFO_BITRATE32, 24019)...),
+			want: 2401.9,
+		},
+		{name: "no rate attributes", in: nlAttrUint32(unix.NL80211_RATE_INFO_MCS, 9), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nlBitrate(tt.in); got != tt.want {
+				t.Errorf("got %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSIDFromIEs(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{name: "first element", in: []byte{0, 4, 'h', 'o', 'm', 'e', 1, 2, 0x82, 0x84}, want: "home"},
+		{name: "after other elements", in: []byte{1, 2, 0x82, 0x84, 0, 3, 'l', 'a', 'b'}, want: "lab"},
+		{name: "hidden", in: []byte{0, 0, 1, 1, 0x82}, want: ""},
+		{name: "truncated", in: []byte{0, 8, 'h', 'o'}, want: ""},
+		{name: "missing", in: []byte{1, 1, 0x82}, want: ""},
+		{name: "empty", in: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ssidFromIEs(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
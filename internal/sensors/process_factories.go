@@ -0,0 +1,310 @@
+package sensors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+const (
+	processCount = "count"
+	processCPU   = "cpu"
+	processRSS   = "rss"
+	processAlive = "alive"
+)
+
+var processMetrics = []string{processCount, processCPU, processRSS, processAlive}
+
+var processMetricNames = map[string]string{
+	processCount: "count",
+	processCPU:   "CPU",
+	processRSS:   "memory",
+	processAlive: "running",
+}
+
+// processSample is the state of one matched process at a collection.
+type processSample struct {
+	createTime int64
+	// cpuSeconds is the user and system time used so far.
+	cpuSeconds float64
+	rss        uint64
+}
+
+type processSensor struct {
+	base
+	match *processMatcher
+	// snapshot holds the matching processes of the last two reads, so the
+	// CPU usage of each process can be computed between them.
+	snapshot *tickCache[counterWindow[map[int32]processSample]]
+	metric   string
+}
+
+// processAliveSensor is announced as a binary sensor.
+type processAliveSensor struct {
+	*processSensor
+}
+
+func (processAliveSensor) binary() {}
+
+func newProcessSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	match, err := newProcessMatcher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := selectMetrics(cfg.Metrics, processMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only CPU usage compares two reads; the other metrics need no baseline.
+	snapshot := newCounterCache(cfg.Interval, slices.Contains(metrics, processCPU), match.read)
+
+	out := make([]Sensor, 0, len(metrics))
+	for _, m := range metrics {
+		sKey := key + "_" + m
+		sName := fmt.Sprintf("%s %s", cfg.Name, processMetricNames[m])
+
+		ha := cfg.HA
+		switch m {
+		case processCPU:
+			ha = haWithStateClass(haWithDefaults(cfg.HA, "%", ""), "measurement")
+		case processRSS:
+			ha = haWithDefaults(cfg.HA, "B", "data_size")
+		case processAlive:
+			ha = haWithDefaults(cfg.HA, "", "running")
+		}
+
+		s := &processSensor{
+			base:     base{key: sKey, name: sName, interval: cfg.Interval, ha: ha},
+			match:    match,
+			snapshot: snapshot,
+			metric:   m,
+		}
+		if m == processAlive {
+			out = append(out, processAliveSensor{processSensor: s})
+			continue
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (s *processSensor) Collect(ctx context.Context) (Reading, error) {
+	w, _, err := s.snapshot.get(ctx)
+	if err != nil {
+		return unavailable(), fmt.Errorf("process(%s): %w", s.match.desc, err)
+	}
+
+	procs := w.cur
+
+	switch s.metric {
+	case processCount:
+		return number(float64(len(procs)), 0), nil
+
+	case processAlive:
+		return boolean(len(procs) > 0), nil
+
+	case processRSS:
+		var rss uint64
+		for _, p := range procs {
+			rss += p.rss
+		}
+		return number(float64(rss), 0), nil
+
+	case processCPU:
+		// Processes started since the previous read (or PIDs reused by
+		// another process) only establish their baseline.
+		var used float64
+		for pid, cur := range procs {
+			if p, ok := w.prev[pid]; ok && p.createTime == cur.createTime && cur.cpuSeconds >= p.cpuSeconds {
+				used += cur.cpuSeconds - p.cpuSeconds
+			}
+		}
+		rate, ok := w.perSecond(used)
+		if !ok {
+			return unavailable(), nil
+		}
+		return number(rate*100, 1), nil
+
+	default:
+		return unavailable(), fmt.Errorf("process: unknown metric %q", s.metric)
+	}
+}
+
+// processMatcher selects processes by exactly one criterion.
+type processMatcher struct {
+	desc string
+
+	name    string
+	cmdline *regexp.Regexp
+	pidFile string
+	cgroup  string
+}
+
+func newProcessMatcher(cfg config.SensorConfig) (*processMatcher, error) {
+	set := 0
+	for _, v := range []string{cfg.ProcessName, cfg.Cmdline, cfg.PIDFile, cfg.Cgroup} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of process_name, cmdline, pidfile or cgroup is required")
+	}
+
+	m := &processMatcher{name: cfg.ProcessName, pidFile: cfg.PIDFile}
+
+	switch {
+	case cfg.ProcessName != "":
+		m.desc = cfg.ProcessName
+	case cfg.Cmdline != "":
+		re, err := regexp.Compile(cfg.Cmdline)
+		if err != nil {
+			return nil, fmt.Errorf("cmdline is invalid: %w", err)
+		}
+		m.cmdline, m.desc = re, cfg.Cmdline
+	case cfg.PIDFile != "":
+		if !filepath.IsAbs(cfg.PIDFile) {
+			return nil, fmt.Errorf("pidfile must be absolute (got: %s)", cfg.PIDFile)
+		}
+		m.desc = cfg.PIDFile
+	case cfg.Cgroup != "":
+		m.cgroup = filepath.Join(cgroupRoot, filepath.Clean("/"+cfg.Cgroup))
+		m.desc = cfg.Cgroup
+	}
+	return m, nil
+}
+
+// pids returns the PIDs of matching processes. A missing pidfile
+// or cgroup means no process is running.
+func (m *processMatcher) pids(ctx context.Context) ([]int32, error) {
+	switch {
+	case m.pidFile != "":
+		data, err := os.ReadFile(m.pidFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pid, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 32)
+		if err != nil || pid <= 0 {
+			return nil, fmt.Errorf("invalid pid in %s", m.pidFile)
+		}
+		// A stale pidfile may point to a process that no longer exists.
+		if ok, _ := process.PidExistsWithContext(ctx, int32(pid)); !ok {
+			return nil, nil
+		}
+		return []int32{int32(pid)}, nil
+
+	case m.cgroup != "":
+		return cgroupPIDs(m.cgroup)
+
+	default:
+		procs, err := process.ProcessesWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var out []int32
+		for _, p := range procs {
+			// Processes may exit while being inspected; skip them.
+			if m.cmdline != nil {
+				cmd, err := p.CmdlineWithContext(ctx)
+				if err == nil && cmd != "" && m.cmdline.MatchString(cmd) {
+					out = append(out, p.Pid)
+				}
+				continue
+			}
+			if name, err := p.NameWithContext(ctx); err == nil && name == m.name {
+				out = append(out, p.Pid)
+			}
+		}
+		return out, nil
+	}
+}
+
+// cgroupPIDs returns the processes of a cgroup v2 directory
+// and all of its child cgroups.
+func cgroupPIDs(dir string) ([]int32, error) {
+	var out []int32
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The cgroup (or a child) was removed, e.g. a stopped service.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		data, err := os.ReadFile(filepath.Join(path, "cgroup.procs"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			if pid, err := strconv.ParseInt(sc.Text(), 10, 32); err == nil {
+				out = append(out, int32(pid))
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+// read samples the matching processes.
+func (m *processMatcher) read(ctx context.Context) (map[int32]processSample, error) {
+	pids, err := m.pids(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[int32]processSample, len(pids))
+	for _, pid := range pids {
+		p, err := process.NewProcessWithContext(ctx, pid)
+		if err != nil {
+			continue
+		}
+
+		created, err := p.CreateTimeWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		times, err := p.TimesWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		mem, err := p.MemoryInfoWithContext(ctx)
+		if err != nil {
+			continue
+		}
+
+		out[pid] = processSample{
+			createTime: created,
+			cpuSeconds: times.User + times.System,
+			rss:        mem.RSS,
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,78 @@
+package sensors
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var procStatPath = "/proc/stat"
+
+// cpuTimes holds cumulative CPU time counters from /proc/stat, in USER_HZ ticks.
+type cpuTimes struct {
+	User    uint64
+	Nice    uint64
+	System  uint64
+	Idle    uint64
+	Iowait  uint64
+	Irq     uint64
+	Softirq uint64
+	Steal   uint64
+}
+
+// total excludes guest time, which the kernel already accounts in user and nice.
+func (t cpuTimes) total() uint64 {
+	return t.User + t.Nice + t.System + t.Idle + t.Iowait + t.Irq + t.Softirq + t.Steal
+}
+
+// readCPUTimes returns counters keyed by /proc/stat label:
+// "cpu" for the aggregate line and "cpuN" for each logical CPU.
+func readCPUTimes() (map[string]cpuTimes, error) {
+	data, err := os.ReadFile(procStatPath)
+	if err != nil {
+		return nil, fmt.Errorf("read %s failed: %w", procStatPath, err)
+	}
+
+	out := make(map[string]cpuTimes)
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		vals := make([]uint64, 8)
+		for i := 0; i < len(vals) && i+1 < len(fields); i++ {
+			v, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s line %q failed: %w", procStatPath, fields[0], err)
+			}
+			vals[i] = v
+		}
+
+		out[fields[0]] = cpuTimes{
+			User:    vals[0],
+			Nice:    vals[1],
+			System:  vals[2],
+			Idle:    vals[3],
+			Iowait:  vals[4],
+			Irq:     vals[5],
+			Softirq: vals[6],
+			Steal:   vals[7],
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("scan %s failed: %w", procStatPath, err)
+	}
+
+	if _, ok := out["cpu"]; !ok {
+		return nil, fmt.Errorf("no cpu line in %s", procStatPath)
+	}
+
+	return out, nil
+}
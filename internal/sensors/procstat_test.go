@@ -0,0 +1,80 @@
+package sensors
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadCPUTimes(t *testing.T) {
+	tests := []struct {
+		name    string
+		stat    string
+		want    map[string]cpuTimes
+		wantErr bool
+	}{
+		{
+			name: "all columns",
+			stat: "cpu  100 2 30 400 5 6 7 8 9 10\n" +
+				"cpu0 50 1 15 200 2 3 3 4 0 0\n" +
+				"cpu1 50 1 15 200 3 3 4 4 0 0\n" +
+				"intr 12345 0 1\nctxt 999\nbtime 1700000000\n",
+			want: map[string]cpuTimes{
+				"cpu":  {User: 100, Nice: 2, System: 30, Idle: 400, Iowait: 5, Irq: 6, Softirq: 7, Steal: 8},
+				"cpu0": {User: 50, Nice: 1, System: 15, Idle: 200, Iowait: 2, Irq: 3, Softirq: 3, Steal: 4},
+				"cpu1": {User: 50, Nice: 1, System: 15, Idle: 200, Iowait: 3, Irq: 3, Softirq: 4, Steal: 4},
+			},
+		},
+		{
+			// Kernels before 2.6.11 have no steal column.
+			name: "old kernel",
+			stat: "cpu 10 0 5 100 1 0 0\n",
+			want: map[string]cpuTimes{"cpu": {User: 10, System: 5, Idle: 100, Iowait: 1}},
+		},
+		{name: "no aggregate line", stat: "cpu0 1 2 3 4 5 6 7 8\n", wantErr: true},
+		{name: "invalid counter", stat: "cpu 1 2 x 4 5 6 7 8\n", wantErr: true},
+		{name: "empty", stat: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeProcStat(t, tt.stat)
+
+			got, err := readCPUTimes()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCPUTimePercent(t *testing.T) {
+	prev := cpuTimes{User: 100, System: 50, Idle: 800, Iowait: 50}
+	// 200 ticks: 60 user, 20 system, 20 irq+softirq, 80 idle, 20 iowait.
+	cur := cpuTimes{User: 160, System: 70, Idle: 880, Iowait: 70, Irq: 10, Softirq: 10}
+
+	tests := []struct {
+		field cpuTimeField
+		want  float64
+	}{
+		{cpuTimeBusy, 50},
+		{cpuTimeUser, 30},
+		{cpuTimeSystem, 10},
+		{cpuTimeIowait, 10},
+		{cpuTimeIrq, 10},
+		{cpuTimeSteal, 0},
+	}
+	for _, tt := range tests {
+		if got := cpuTimePercent(prev, cur, tt.field); got != tt.want {
+			t.Errorf("field %d: got %g, want %g", tt.field, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package sensors
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// promSample is one series of a Prometheus text exposition.
+type promSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+	// raw is the value as exposed, used to keep its decimals.
+	raw string
+}
+
+// parsePromText parses the Prometheus text exposition format.
+// Comments, metadata and timestamps are ignored.
+func parsePromText(data []byte) ([]promSample, error) {
+	var out []promSample
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64<<10), httpReadLimit)
+
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		s, err := parsePromLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		out = append(out, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func parsePromLine(line string) (promSample, error) {
+	s := promSample{}
+
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return s, fmt.Errorf("invalid sample %q", line)
+	}
+	s.name, line = line[:end], line[end:]
+
+	if line[0] == '{' {
+		labels, rest, err := parsePromLabels(line[1:])
+		if err != nil {
+			return s, err
+		}
+		s.labels, line = labels, rest
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 || len(fields) > 2 {
+		return s, fmt.Errorf("invalid value for %s", s.name)
+	}
+
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s, fmt.Errorf("invalid value for %s: %q", s.name, fields[0])
+	}
+	s.value, s.raw = v, fields[0]
+	return s, nil
+}
+
+// parsePromLabels parses `name="value",...}` and returns the rest of the line.
+func parsePromLabels(s string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return nil, "", errors.New("unterminated label set")
+		}
+		if s[0] == '}' {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("invalid label in %q", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+
+		val, rest, err := parsePromQuoted(strings.TrimLeft(s[eq+1:], " \t"))
+		if err != nil {
+			return nil, "", fmt.Errorf("label %s: %w", name, err)
+		}
+		labels[name] = val
+
+		s = strings.TrimLeft(rest, " \t")
+		s = strings.TrimPrefix(s, ",")
+	}
+}
+
+// parsePromQuoted parses a double-quoted label value with \\, \" and \n escapes.
+func parsePromQuoted(s string) (string, string, error) {
+	if s == "" || s[0] != '"' {
+		return "", "", errors.New("value must be quoted")
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", errors.New("unterminated escape")
+			}
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated value")
+}
+
+// promSelector matches series by metric name and label matchers,
+// written as in PromQL: name{label="v",label!="v",label=~"re",label!~"re"}.
+type promSelector struct {
+	name     string
+	matchers []promMatcher
+}
+
+type promMatcher struct {
+	label  string
+	negate bool
+	value  string
+	re     *regexp.Regexp
+}
+
+var promMatcherOps = []string{"!~", "=~", "!=", "="}
+
+func parsePromSelector(s string) (*promSelector, error) {
+	s = strings.TrimSpace(s)
+
+	name, rest, hasLabels := strings.Cut(s, "{")
+	sel := &promSelector{name: strings.TrimSpace(name)}
+	if sel.name == "" {
+		return nil, errors.New("metric name is empty")
+	}
+	if !hasLabels {
+		return sel, nil
+	}
+
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return nil, errors.New("unterminated label matchers")
+		}
+		if rest[0] == '}' {
+			if strings.TrimSpace(rest[1:]) != "" {
+				return nil, fmt.Errorf("unexpected %q after label matchers", rest[1:])
+			}
+			return sel, nil
+		}
+
+		i := strings.IndexAny(rest, "!=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid label matcher in %q", rest)
+		}
+		m := promMatcher{label: strings.TrimSpace(rest[:i])}
+
+		var op string
+		for _, o := range promMatcherOps {
+			if strings.HasPrefix(rest[i:], o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("invalid operator for label %s", m.label)
+		}
+
+		val, after, err := parsePromQuoted(strings.TrimLeft(rest[i+len(op):], " \t"))
+		if err != nil {
+			return nil, fmt.Errorf("label %s: %w", m.label, err)
+		}
+		m.value, m.negate = val, op[0] == '!'
+
+		if strings.HasSuffix(op, "~") {
+			// Label regexes are anchored, as in PromQL.
+			re, err := regexp.Compile("^(?:" + val + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("label %s: invalid regex: %w", m.label, err)
+			}
+			m.re = re
+		}
+		sel.matchers = append(sel.matchers, m)
+
+		rest = strings.TrimLeft(after, " \t")
+		rest = strings.TrimPrefix(rest, ",")
+	}
+}
+
+func (sel *promSelector) matches(s promSample) bool {
+	if s.name != sel.name {
+		return false
+	}
+	for _, m := range sel.matchers {
+		// A missing label matches as an empty value.
+		v := s.labels[m.label]
+
+		var ok bool
+		if m.re != nil {
+			ok = m.re.MatchString(v)
+		} else {
+			ok = v == m.value
+		}
+		if ok == m.negate {
+			return false
+		}
+	}
+	return true
+}
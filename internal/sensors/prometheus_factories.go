@@ -0,0 +1,252 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/keys"
+)
+
+// promReadLimit bounds the size of a scrape; exporters such as cAdvisor
+// or kube-state-metrics expose several megabytes of series.
+const promReadLimit = 16 << 20
+
+type prometheusSensor struct {
+	base
+	scrape    *promScrape
+	metric    string
+	selector  *promSelector
+	aggregate string
+	rate      bool
+}
+
+// newPrometheusSensors creates one sensor for metric, or one sensor per entry
+// of values. Sensors scraping the same endpoint in the same interval group
+// share one scrape per tick.
+func newPrometheusSensors(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("url is required")
+	}
+
+	switch cfg.Aggregate {
+	case "", "sum", "max", "min", "avg":
+	default:
+		return nil, fmt.Errorf("aggregate must be one of: sum, max, min, avg (got: %s)", cfg.Aggregate)
+	}
+
+	rates := cfg.Rate
+	for _, v := range cfg.Values {
+		rates = rates || v.Rate
+	}
+	scrape := sharedPromScrape(res, cfg, rates)
+
+	if len(cfg.Values) == 0 {
+		if cfg.Metric == "" {
+			return nil, errors.New("metric is required")
+		}
+		sel, err := parsePromSelector(cfg.Metric)
+		if err != nil {
+			return nil, fmt.Errorf("metric is invalid: %w", err)
+		}
+		out := []Sensor{&prometheusSensor{
+			base:      base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
+			scrape:    scrape,
+			metric:    cfg.Metric,
+			selector:  sel,
+			aggregate: cfg.Aggregate,
+			rate:      cfg.Rate,
+		}}
+		return out, nil
+	}
+
+	if cfg.Metric != "" {
+		return nil, errors.New("metric must be set per value when values are used")
+	}
+
+	names := slices.Sorted(maps.Keys(cfg.Values))
+
+	out := make([]Sensor, 0, len(names))
+	for _, name := range names {
+		v := cfg.Values[name]
+
+		suffix := keys.Sanitize(name)
+		if suffix == "" {
+			return nil, fmt.Errorf("values.%s: key must contain letters or digits", name)
+		}
+		if v.Metric == "" {
+			return nil, fmt.Errorf("values.%s: metric is required", name)
+		}
+		sel, err := parsePromSelector(v.Metric)
+		if err != nil {
+			return nil, fmt.Errorf("values.%s: metric is invalid: %w", name, err)
+		}
+
+		sName := v.Name
+		if sName == "" {
+			sName = fmt.Sprintf("%s %s", cfg.Name, name)
+		}
+
+		out = append(out, &prometheusSensor{
+			base: base{
+				key:      key + "_" + suffix,
+				name:     sName,
+				interval: cfg.Interval,
+				ha:       haWithOverrides(cfg.HA, v.HA),
+			},
+			scrape:    scrape,
+			metric:    v.Metric,
+			selector:  sel,
+			aggregate: cfg.Aggregate,
+			rate:      cfg.Rate || v.Rate,
+		})
+	}
+	return out, nil
+}
+
+func (s *prometheusSensor) Collect(ctx context.Context) (Reading, error) {
+	w, _, err := s.scrape.samples.get(ctx)
+	if err != nil {
+		return unavailable(), fmt.Errorf("prometheus(%s): %w", s.scrape.url, err)
+	}
+
+	v, precision, err := s.value(w.cur)
+	if err != nil {
+		return unavailable(), fmt.Errorf("prometheus(%s): %w", s.scrape.url, err)
+	}
+
+	if !s.rate {
+		return number(v, precision), nil
+	}
+	return s.rateOf(w, v), nil
+}
+
+// value combines the samples matching the selector, and returns the
+// number of decimals to publish it with.
+func (s *prometheusSensor) value(samples []promSample) (float64, int, error) {
+	var (
+		values    []float64
+		precision int
+	)
+	for _, smp := range samples {
+		if s.selector.matches(smp) {
+			// NaN and ±Inf are valid in the exposition format
+			// but have no state to publish.
+			if math.IsNaN(smp.value) || math.IsInf(smp.value, 0) {
+				return 0, 0, fmt.Errorf("%s has no numeric value (%s)", s.metric, smp.raw)
+			}
+			values = append(values, smp.value)
+			precision = max(precision, parseValue(smp.raw).Precision)
+		}
+	}
+	if len(values) == 0 {
+		return 0, 0, fmt.Errorf("no series matches %s", s.metric)
+	}
+
+	var v float64
+	switch s.aggregate {
+	case "max":
+		v = slices.Max(values)
+	case "min":
+		v = slices.Min(values)
+	case "avg":
+		for _, x := range values {
+			v += x
+		}
+		v /= float64(len(values))
+		precision = max(precision, 2)
+	default:
+		for _, x := range values {
+			v += x
+		}
+	}
+
+	return v, precision, nil
+}
+
+// rateOf returns the per-second increase of v since the previous scrape.
+func (s *prometheusSensor) rateOf(w counterWindow[[]promSample], v float64) Reading {
+	if w.prev == nil {
+		return unavailable()
+	}
+	// The series may have been missing in the previous scrape.
+	prev, _, err := s.value(w.prev)
+	if err != nil {
+		return unavailable()
+	}
+
+	// Counters going backwards mean the exporter restarted; start over.
+	if v < prev {
+		return unavailable()
+	}
+	rate, ok := w.perSecond(v - prev)
+	if !ok {
+		return unavailable()
+	}
+	return number(rate, 2)
+}
+
+// promScrape scrapes an endpoint and shares the parsed samples between
+// sensors of one interval group.
+type promScrape struct {
+	url     string
+	samples *tickCache[counterWindow[[]promSample]]
+	// baseline is set when a rate sensor uses the scrape, which then
+	// scrapes once at startup.
+	baseline bool
+}
+
+type promScrapeKey struct {
+	url      string
+	headers  string
+	accept   string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func sharedPromScrape(res *resources, cfg config.SensorConfig, baseline bool) *promScrape {
+	var headers strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(cfg.Headers)) {
+		fmt.Fprintf(&headers, "%s: %s\n", k, cfg.Headers[k])
+	}
+
+	k := promScrapeKey{
+		url:      cfg.URL,
+		headers:  headers.String(),
+		accept:   fmt.Sprint(cfg.AcceptStatus),
+		timeout:  cfg.Timeout,
+		interval: cfg.Interval,
+	}
+	c, ok := res.promScrapes[k]
+	if ok && (c.baseline || !baseline) {
+		return c
+	}
+
+	req := newHTTPRequest(cfg, promReadLimit)
+	fetch := func(ctx context.Context) ([]promSample, error) {
+		body, err := req.do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		samples, err := parsePromText(body)
+		if err != nil {
+			return nil, fmt.Errorf("parse metrics failed: %w", err)
+		}
+		return samples, nil
+	}
+	if !ok {
+		c = &promScrape{url: cfg.URL}
+		res.promScrapes[k] = c
+	}
+	// Sensors hold the scrape, not the cache, so a rate sensor joining
+	// a scrape created without a baseline can replace its cache.
+	c.samples = newCounterCache(cfg.Interval, baseline, fetch)
+	c.baseline = baseline
+	return c
+}
@@ -0,0 +1,219 @@
+package sensors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestParsePromText(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []promSample
+		wantErr bool
+	}{
+		{
+			name: "comments and blank lines",
+			in:   "# HELP up Target up.\n# TYPE up gauge\n\nup 1\n",
+			want: []promSample{{name: "up", value: 1, raw: "1"}},
+		},
+		{
+			name: "labels and timestamp",
+			in:   `http_requests_total{method="GET",code="200"} 1027 1395066363000`,
+			want: []promSample{{
+				name:   "http_requests_total",
+				labels: map[string]string{"method": "GET", "code": "200"},
+				value:  1027,
+				raw:    "1027",
+			}},
+		},
+		{
+			name: "escaped label value",
+			in:   `msg{text="a \"quoted\"\nline\\"} 0.50`,
+			want: []promSample{{
+				name:   "msg",
+				labels: map[string]string{"text": "a \"quoted\"\nline\\"},
+				value:  0.5,
+				raw:    "0.50",
+			}},
+		},
+		{
+			name: "trailing comma and spaces",
+			in:   `temp{ chip = "k10temp", } -1.5e1`,
+			want: []promSample{{
+				name:   "temp",
+				labels: map[string]string{"chip": "k10temp"},
+				value:  -15,
+				raw:    "-1.5e1",
+			}},
+		},
+		{
+			name: "infinities",
+			in:   "le_max +Inf\nle_min -Inf\n",
+			want: []promSample{
+				{name: "le_max", value: math.Inf(1), raw: "+Inf"},
+				{name: "le_min", value: math.Inf(-1), raw: "-Inf"},
+			},
+		},
+		{name: "unterminated labels", in: `up{job="a" 1`, wantErr: true},
+		{name: "unquoted label", in: `up{job=a} 1`, wantErr: true},
+		{name: "missing value", in: `up{job="a"}`, wantErr: true},
+		{name: "invalid value", in: `up abc`, wantErr: true},
+		{name: "too many fields", in: `up 1 2 3`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePromText([]byte(tt.in))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrometheusNonFiniteUnavailable(t *testing.T) {
+	samples, err := parsePromText([]byte("ratio NaN\nlatency{q=\"1\"} +Inf\nlatency{q=\"0.5\"} 0.25\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 3 || !math.IsNaN(samples[0].value) {
+		t.Fatalf("got %+v, want NaN parsed as a sample", samples)
+	}
+
+	for _, metric := range []string{"ratio", "latency"} {
+		sel, err := parsePromSelector(metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &prometheusSensor{metric: metric, selector: sel}
+		if _, _, err := s.value(samples); err == nil {
+			t.Errorf("%s: expected an error for a non-finite value", metric)
+		}
+	}
+}
+
+func TestPromSelector(t *testing.T) {
+	samples := []promSample{
+		{name: "net_rx_total", labels: map[string]string{"device": "eth0"}},
+		{name: "net_rx_total", labels: map[string]string{"device": "eth1"}},
+		{name: "net_rx_total", labels: map[string]string{"device": "lo"}},
+		{name: "net_rx_total"},
+		{name: "net_tx_total", labels: map[string]string{"device": "eth0"}},
+	}
+
+	tests := []struct {
+		selector string
+		// want holds the indexes of the matching samples.
+		want    []int
+		wantErr bool
+	}{
+		{selector: "net_rx_total", want: []int{0, 1, 2, 3}},
+		{selector: `net_rx_total{device="eth0"}`, want: []int{0}},
+		{selector: `net_rx_total{device!="lo"}`, want: []int{0, 1, 3}},
+		{selector: `net_rx_total{device=~"eth.*"}`, want: []int{0, 1}},
+		// Regexes are anchored.
+		{selector: `net_rx_total{device=~"eth"}`, want: nil},
+		{selector: `net_rx_total{device!~"eth.*"}`, want: []int{2, 3}},
+		// A missing label matches as an empty value.
+		{selector: `net_rx_total{device=""}`, want: []int{3}},
+		{selector: ` net_tx_total { device = "eth0" , } `, want: []int{4}},
+		{selector: "", wantErr: true},
+		{selector: `{device="eth0"}`, wantErr: true},
+		{selector: `net_rx_total{device="eth0"`, wantErr: true},
+		{selector: `net_rx_total{device~"eth0"}`, wantErr: true},
+		{selector: `net_rx_total{device=eth0}`, wantErr: true},
+		{selector: `net_rx_total{device=~"("}`, wantErr: true},
+		{selector: `net_rx_total{device="eth0"} extra`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := parsePromSelector(tt.selector)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []int
+			for i, s := range samples {
+				if sel.matches(s) {
+					got = append(got, i)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrometheusRateStartsFromBaseline(t *testing.T) {
+	var scrapes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := scrapes.Add(1)
+		fmt.Fprintf(w, "bytes_total{dev=\"a\"} %d\nbytes_total{dev=\"b\"} %d\n", n*100, n*100)
+	}))
+	defer srv.Close()
+
+	// The value without rate is collected first and fills the shared scrape.
+	// The first collection waits for a full interval after the baseline.
+	const interval = 100 * time.Millisecond
+	got, err := newPrometheusSensors("p", config.SensorConfig{
+		Interval: interval,
+		URL:      srv.URL,
+		Values: map[string]config.SensorValueConfig{
+			"a_total": {Metric: "bytes_total"},
+			"b_rate":  {Metric: "bytes_total", Rate: true},
+		},
+	}, newResources())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := scrapes.Load(); n != 1 {
+		t.Fatalf("got %d baseline scrapes, want 1", n)
+	}
+
+	var readings []Reading
+	for _, s := range got {
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		readings = append(readings, r)
+	}
+	if n := scrapes.Load(); n != 2 {
+		t.Errorf("got %d scrapes, want 2", n)
+	}
+
+	if want := number(400, 0); readings[0] != want {
+		t.Errorf("total = %+v, want %+v", readings[0], want)
+	}
+	// 200 bytes over at least one interval.
+	if r := readings[1]; r.Kind != ReadingNumber || r.Value <= 0 || r.Value > 200/interval.Seconds() {
+		t.Errorf("rate = %+v, want a rate in (0, %g]", r, 200/interval.Seconds())
+	}
+}
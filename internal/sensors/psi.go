@@ -0,0 +1,91 @@
+package sensors
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// psiLine is one line of a pressure stall information file.
+type psiLine struct {
+	// Avg10, Avg60 and Avg300 are the share of time (%) tasks were stalled
+	// over the last 10, 60 and 300 seconds.
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	// Total is the cumulative stall time in microseconds.
+	Total uint64
+}
+
+// psiStats holds the "some" and "full" lines of a pressure file.
+// CPU pressure has no "full" line before Linux 5.13.
+type psiStats struct {
+	Some    psiLine
+	Full    psiLine
+	HasFull bool
+}
+
+// readPSI parses a pressure file such as /proc/pressure/memory
+// or memory.pressure of a cgroup:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func readPSI(path string) (psiStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return psiStats{}, err
+	}
+	return parsePSI(data)
+}
+
+func parsePSI(data []byte) (psiStats, error) {
+	var out psiStats
+	hasSome := false
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var line psiLine
+		for _, f := range fields[1:] {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				return psiStats{}, fmt.Errorf("invalid pressure field %q", f)
+			}
+
+			var err error
+			switch k {
+			case "avg10":
+				line.Avg10, err = strconv.ParseFloat(v, 64)
+			case "avg60":
+				line.Avg60, err = strconv.ParseFloat(v, 64)
+			case "avg300":
+				line.Avg300, err = strconv.ParseFloat(v, 64)
+			case "total":
+				line.Total, err = strconv.ParseUint(v, 10, 64)
+			}
+			if err != nil {
+				return psiStats{}, fmt.Errorf("invalid pressure field %q", f)
+			}
+		}
+
+		switch fields[0] {
+		case "some":
+			out.Some, hasSome = line, true
+		case "full":
+			out.Full, out.HasFull = line, true
+		}
+	}
+
+	if !hasSome {
+		return psiStats{}, errors.New(`no "some" line in pressure data`)
+	}
+	return out, nil
+}
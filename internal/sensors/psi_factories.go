@@ -0,0 +1,143 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+var procPressureDir = "/proc/pressure"
+
+const (
+	psiSomeAvg10  = "some_avg10"
+	psiSomeAvg60  = "some_avg60"
+	psiSomeAvg300 = "some_avg300"
+	psiSomeTotal  = "some_total"
+	psiFullAvg10  = "full_avg10"
+	psiFullAvg60  = "full_avg60"
+	psiFullAvg300 = "full_avg300"
+	psiFullTotal  = "full_total"
+)
+
+var psiMetrics = []string{
+	psiSomeAvg10, psiSomeAvg60, psiSomeAvg300, psiSomeTotal,
+	psiFullAvg10, psiFullAvg60, psiFullAvg300, psiFullTotal,
+}
+
+// psiCPUDefaultMetrics leaves out "full", which is always zero for CPU
+// at the system level.
+var psiCPUDefaultMetrics = []string{psiSomeAvg10, psiSomeAvg60, psiSomeAvg300, psiSomeTotal}
+
+var psiMetricNames = map[string]string{
+	psiSomeAvg10:  "some 10s",
+	psiSomeAvg60:  "some 1m",
+	psiSomeAvg300: "some 5m",
+	psiSomeTotal:  "some stall time",
+	psiFullAvg10:  "full 10s",
+	psiFullAvg60:  "full 1m",
+	psiFullAvg300: "full 5m",
+	psiFullTotal:  "full stall time",
+}
+
+// psiSensor reports one value of a /proc/pressure file.
+type psiSensor struct {
+	base
+	path string
+	// snapshot holds the last two reads of the file, so the total stall
+	// time can be turned into a rate between them.
+	snapshot *tickCache[counterWindow[psiStats]]
+	metric   string
+}
+
+// newPSISensors creates one sensor per metric for resource
+// (cpu, memory or io).
+func newPSISensors(key string, cfg config.SensorConfig, resource string) ([]Sensor, error) {
+	metrics, err := selectMetrics(cfg.Metrics, psiMetrics)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Metrics) == 0 && resource == "cpu" {
+		metrics = psiCPUDefaultMetrics
+	}
+
+	path := filepath.Join(procPressureDir, resource)
+
+	// Only the totals compare two reads; the averages are kept by the kernel.
+	totals := slices.Contains(metrics, psiSomeTotal) || slices.Contains(metrics, psiFullTotal)
+	snapshot := newCounterCache(cfg.Interval, totals, func(context.Context) (psiStats, error) {
+		psi, err := readPSI(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = errors.New("pressure stall information is not available (kernel without CONFIG_PSI or booted with psi=0?)")
+		}
+		return psi, err
+	})
+
+	out := make([]Sensor, 0, len(metrics))
+	for _, m := range metrics {
+		ha := haWithDefaults(cfg.HA, "%", "")
+		if m == psiSomeTotal || m == psiFullTotal {
+			ha = haWithDefaults(cfg.HA, "µs/s", "")
+		}
+
+		out = append(out, &psiSensor{
+			base: base{
+				key:      key + "_" + m,
+				name:     fmt.Sprintf("%s %s", cfg.Name, psiMetricNames[m]),
+				interval: cfg.Interval,
+				ha:       ha,
+			},
+			path:     path,
+			snapshot: snapshot,
+			metric:   m,
+		})
+	}
+	return out, nil
+}
+
+func (s *psiSensor) Collect(ctx context.Context) (Reading, error) {
+	w, _, err := s.snapshot.get(ctx)
+	if err != nil {
+		return unavailable(), fmt.Errorf("psi(%s): %w", s.path, err)
+	}
+
+	full := strings.HasPrefix(s.metric, "full_")
+	if full && !w.cur.HasFull {
+		return unavailable(), fmt.Errorf("psi(%s): full pressure is not reported by the kernel", s.path)
+	}
+	line := w.cur.Some
+	if full {
+		line = w.cur.Full
+	}
+
+	switch s.metric {
+	case psiSomeAvg10, psiFullAvg10:
+		return number(line.Avg10, 2), nil
+	case psiSomeAvg60, psiFullAvg60:
+		return number(line.Avg60, 2), nil
+	case psiSomeAvg300, psiFullAvg300:
+		return number(line.Avg300, 2), nil
+	case psiSomeTotal, psiFullTotal:
+		prev := w.prev.Some.Total
+		if full {
+			prev = w.prev.Full.Total
+		}
+		if line.Total < prev {
+			return unavailable(), nil
+		}
+		// Stall time in microseconds per second; 1000000 means stalled
+		// for the whole window.
+		rate, ok := w.perSecond(float64(line.Total - prev))
+		if !ok {
+			return unavailable(), nil
+		}
+		return number(rate, 0), nil
+	default:
+		return unavailable(), fmt.Errorf("psi: unknown metric %q", s.metric)
+	}
+}
@@ -0,0 +1,114 @@
+package sensors
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestPSISensors(t *testing.T) {
+	dir := t.TempDir()
+	prev := procPressureDir
+	procPressureDir = dir
+	t.Cleanup(func() { procPressureDir = prev })
+
+	writePressure := func(someTotal, fullTotal string) {
+		writeFile(t, filepath.Join(dir, "io"),
+			"some avg10=1.25 avg60=0.50 avg300=0.10 total="+someTotal+"\n"+
+				"full avg10=0.75 avg60=0.25 avg300=0.05 total="+fullTotal+"\n")
+	}
+	writePressure("1000000", "500000")
+
+	// The first collection waits for a full interval after the baseline.
+	const interval = 100 * time.Millisecond
+	got, err := newPSISensors("io", config.SensorConfig{Name: "I/O pressure", Interval: interval}, "io")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stall time grows between the startup baseline and the first collection.
+	writePressure("1100000", "500000")
+
+	readings := make(map[string]Reading, len(got))
+	for _, s := range got {
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		readings[s.Key()] = r
+	}
+
+	want := map[string]Reading{
+		"io_some_avg10":  number(1.25, 2),
+		"io_some_avg60":  number(0.5, 2),
+		"io_some_avg300": number(0.1, 2),
+		"io_full_avg10":  number(0.75, 2),
+		"io_full_avg60":  number(0.25, 2),
+		"io_full_avg300": number(0.05, 2),
+		"io_full_total":  number(0, 0),
+	}
+	if len(readings) != len(want)+1 {
+		t.Errorf("got %d sensors, want %d", len(readings), len(want)+1)
+	}
+	for k, w := range want {
+		if readings[k] != w {
+			t.Errorf("%s = %+v, want %+v", k, readings[k], w)
+		}
+	}
+
+	// 100000µs stalled over at least one interval.
+	max := 100000 / interval.Seconds()
+	if r := readings["io_some_total"]; r.Kind != ReadingNumber || r.Value <= 0 || r.Value > max {
+		t.Errorf("io_some_total = %+v, want a value in (0, %g]", r, max)
+	}
+}
+
+func TestPSICPUDefaultMetrics(t *testing.T) {
+	dir := t.TempDir()
+	prev := procPressureDir
+	procPressureDir = dir
+	t.Cleanup(func() { procPressureDir = prev })
+
+	got, err := newPSISensors("cpu", config.SensorConfig{Interval: time.Minute}, "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(psiCPUDefaultMetrics) {
+		t.Fatalf("got %d sensors, want %d", len(got), len(psiCPUDefaultMetrics))
+	}
+
+	// Without /proc/pressure the sensors are unavailable with an explanation.
+	if r, err := got[0].Collect(context.Background()); err == nil || r.Available() {
+		t.Errorf("got %+v, %v; want unavailable with an error", r, err)
+	}
+}
+
+func TestPSIAveragesSkipBaseline(t *testing.T) {
+	dir := t.TempDir()
+	prev := procPressureDir
+	procPressureDir = dir
+	t.Cleanup(func() { procPressureDir = prev })
+
+	writeFile(t, filepath.Join(dir, "memory"),
+		"some avg10=2.50 avg60=0.00 avg300=0.00 total=0\n"+
+			"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")
+
+	got, err := newPSISensors("mem", config.SensorConfig{Interval: time.Hour, Metrics: []string{"some_avg10"}}, "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a total there is no baseline to wait an interval for.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r, err := got[0].Collect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := number(2.5, 2); r != want {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+}
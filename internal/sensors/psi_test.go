@@ -0,0 +1,58 @@
+package sensors
+
+import (
+	"testing"
+)
+
+func TestParsePSI(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    psiStats
+		wantErr bool
+	}{
+		{
+			name: "some and full",
+			in: "some avg10=1.50 avg60=0.75 avg300=0.10 total=123456\n" +
+				"full avg10=0.50 avg60=0.25 avg300=0.00 total=4567\n",
+			want: psiStats{
+				Some:    psiLine{Avg10: 1.5, Avg60: 0.75, Avg300: 0.1, Total: 123456},
+				Full:    psiLine{Avg10: 0.5, Avg60: 0.25, Total: 4567},
+				HasFull: true,
+			},
+		},
+		{
+			name: "cpu before Linux 5.13",
+			in:   "some avg10=0.00 avg60=0.00 avg300=0.00 total=42\n",
+			want: psiStats{Some: psiLine{Total: 42}},
+		},
+		{
+			name: "unknown fields and blank lines",
+			in:   "\nsome avg10=2.00 avg60=0.00 avg300=0.00 total=7 extra=1\n\n",
+			want: psiStats{Some: psiLine{Avg10: 2, Total: 7}},
+		},
+		{name: "empty", in: "", wantErr: true},
+		{name: "only full", in: "full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n", wantErr: true},
+		{name: "field without value", in: "some avg10 avg60=0.00 avg300=0.00 total=0\n", wantErr: true},
+		{name: "invalid average", in: "some avg10=x avg60=0.00 avg300=0.00 total=0\n", wantErr: true},
+		{name: "negative total", in: "some avg10=0.00 avg60=0.00 avg300=0.00 total=-1\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePSI([]byte(tt.in))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
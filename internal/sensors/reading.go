@@ -0,0 +1,86 @@
+package sensors
+
+import (
+	"strconv"
+	"time"
+)
+
+type ReadingKind uint8
+
+const (
+	// ReadingUnavailable means the sensor has no value: collection failed
+	// or a delta-based sensor only has its first sample.
+	ReadingUnavailable ReadingKind = iota
+	ReadingNumber
+	ReadingText
+	ReadingBool
+	ReadingTimestamp
+)
+
+// Reading is a typed sensor value. It is formatted only when published,
+// so the agent can still compare, convert or round it.
+type Reading struct {
+	Kind ReadingKind
+
+	// Value is set for ReadingNumber. Precision is the number of decimals
+	// used when the sensor has no configured precision.
+	Value     float64
+	Precision int
+	Unit      string
+
+	Text string
+	Bool bool
+	Time time.Time
+}
+
+func unavailable() Reading {
+	return Reading{Kind: ReadingUnavailable}
+}
+
+func number(v float64, precision int) Reading {
+	return Reading{Kind: ReadingNumber, Value: v, Precision: precision}
+}
+
+func text(s string) Reading {
+	return Reading{Kind: ReadingText, Text: s}
+}
+
+func boolean(b bool) Reading {
+	return Reading{Kind: ReadingBool, Bool: b}
+}
+
+func timestamp(t time.Time) Reading {
+	return Reading{Kind: ReadingTimestamp, Time: t}
+}
+
+func (r Reading) Available() bool {
+	return r.Kind != ReadingUnavailable
+}
+
+// Format renders the reading as an MQTT state payload. A non-nil precision
+// overrides the default number of decimals of numeric readings.
+func (r Reading) Format(precision *int) string {
+	switch r.Kind {
+	case ReadingNumber:
+		p := r.Precision
+		if precision != nil {
+			p = *precision
+		}
+		return strconv.FormatFloat(r.Value, 'f', p, 64)
+	case ReadingText:
+		return r.Text
+	case ReadingBool:
+		if r.Bool {
+			return "ON"
+		}
+		return "OFF"
+	case ReadingTimestamp:
+		return r.Time.Format(time.RFC3339)
+	default:
+		return "unavailable"
+	}
+}
+
+func (r Reading) String() string {
+	return r.Format(nil)
+}
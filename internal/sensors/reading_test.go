@@ -0,0 +1,49 @@
+package sensors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadingFormat(t *testing.T) {
+	three := 3
+	zero := 0
+
+	tests := []struct {
+		name      string
+		r         Reading
+		precision *int
+		want      string
+	}{
+		{name: "number", r: number(42.456, 1), want: "42.5"},
+		{name: "integer", r: number(1024, 0), want: "1024"},
+		{name: "configured precision", r: number(42.456, 1), precision: &three, want: "42.456"},
+		{name: "configured zero precision", r: number(42.5, 1), precision: &zero, want: "42"},
+		{name: "negative", r: number(-0.25, 2), want: "-0.25"},
+		{name: "text", r: text("ONLINE"), want: "ONLINE"},
+		{name: "text ignores precision", r: text("1.2345"), precision: &zero, want: "1.2345"},
+		{name: "true", r: boolean(true), want: "ON"},
+		{name: "false", r: boolean(false), want: "OFF"},
+		{name: "timestamp", r: timestamp(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)), want: "2024-03-01T12:00:00Z"},
+		{name: "unavailable", r: unavailable(), want: "unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Format(tt.precision); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadingAvailable(t *testing.T) {
+	if unavailable().Available() {
+		t.Error("unavailable reading reports available")
+	}
+	// Zero values and empty text are values, not missing ones.
+	for _, r := range []Reading{number(0, 0), text(""), boolean(false)} {
+		if !r.Available() {
+			t.Errorf("%+v reports unavailable", r)
+		}
+	}
+}
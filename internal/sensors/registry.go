@@ -1,6 +1,8 @@
 package sensors
 
 import (
+	"strings"
+
 	"github.com/Miklakapi/gometrum/internal/config"
 )
 
@@ -12,8 +14,69 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "%",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newCPUUsageSensor(key, cfg)}, nil
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newCPUUsageSensor(key, cfg, cpuTimeBusy)}, nil
+		},
+	},
+	"cpu_usage_user": {
+		DefaultName:        "CPU user time",
+		DefaultIcon:        "mdi:cpu-64-bit",
+		DefaultUnit:        "%",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newCPUUsageSensor(key, cfg, cpuTimeUser)}, nil
+		},
+	},
+	"cpu_usage_system": {
+		DefaultName:        "CPU system time",
+		DefaultIcon:        "mdi:cpu-64-bit",
+		DefaultUnit:        "%",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newCPUUsageSensor(key, cfg, cpuTimeSystem)}, nil
+		},
+	},
+	"cpu_usage_iowait": {
+		DefaultName:        "CPU iowait",
+		DefaultIcon:        "mdi:cpu-64-bit",
+		DefaultUnit:        "%",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newCPUUsageSensor(key, cfg, cpuTimeIowait)}, nil
+		},
+	},
+	"cpu_usage_steal": {
+		DefaultName:        "CPU steal time",
+		DefaultIcon:        "mdi:cpu-64-bit",
+		DefaultUnit:        "%",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newCPUUsageSensor(key, cfg, cpuTimeSteal)}, nil
+		},
+	},
+	"cpu_usage_irq": {
+		DefaultName:        "CPU interrupt time",
+		DefaultIcon:        "mdi:cpu-64-bit",
+		DefaultUnit:        "%",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newCPUUsageSensor(key, cfg, cpuTimeIrq)}, nil
+		},
+	},
+	"cpu_core_usage": {
+		DefaultName:        "CPU core usage",
+		DefaultIcon:        "mdi:cpu-64-bit",
+		DefaultUnit:        "%",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newCPUCoreUsageSensors(key, cfg)
 		},
 	},
 	"cpu_load_1m": {
@@ -22,7 +85,7 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return []Sensor{newCPULoadSensor(key, cfg, loadWindow1m)}, nil
 		},
 	},
@@ -32,7 +95,7 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return []Sensor{newCPULoadSensor(key, cfg, loadWindow5m)}, nil
 		},
 	},
@@ -42,7 +105,7 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return []Sensor{newCPULoadSensor(key, cfg, loadWindow15m)}, nil
 		},
 	},
@@ -52,8 +115,23 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "°C",
 		DefaultDeviceClass: "temperature",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newCPUTempSensor(key, cfg)}, nil
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			s, err := newCPUTempSensor(key, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return []Sensor{s}, nil
+		},
+	},
+	"temperature": {
+		DefaultName:        "Temperature",
+		DefaultIcon:        "mdi:thermometer",
+		DefaultUnit:        "°C",
+		DefaultDeviceClass: "temperature",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newTemperatureSensors(key, cfg)
 		},
 	},
 
@@ -64,7 +142,7 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "s",
 		DefaultDeviceClass: "duration",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return []Sensor{newUptimeSensor(key, cfg)}, nil
 		},
 	},
@@ -74,7 +152,7 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return []Sensor{newOSVersionSensor(key, cfg)}, nil
 		},
 	},
@@ -84,7 +162,7 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return []Sensor{newHostnameSensor(key, cfg)}, nil
 		},
 	},
@@ -96,7 +174,7 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "%",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return []Sensor{newMemoryUsageSensor(key, cfg)}, nil
 		},
 	},
@@ -106,7 +184,7 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "%",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return []Sensor{newSwapUsageSensor(key, cfg)}, nil
 		},
 	},
@@ -118,10 +196,22 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "%",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
 			return newDiskUsageSensors(key, cfg), nil
 		},
 	},
+	"disk_io": {
+		DefaultName:        "Disk I/O",
+		DefaultIcon:        "mdi:harddisk",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newDiskIOSensors(key, cfg)
+		},
+	},
 
 	// Network
 	"host_ip": {
@@ -130,18 +220,33 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newHostIPSensor(key, cfg)}, nil
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			s, err := newHostIPSensor(key, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return []Sensor{s}, nil
+		},
+	},
+	"net_throughput": {
+		DefaultName:        "Network",
+		DefaultIcon:        "mdi:network",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newNetThroughputSensors(key, cfg)
 		},
 	},
 	"wifi_signal": {
 		DefaultName:        "Wi-Fi signal",
 		DefaultIcon:        "mdi:wifi",
-		DefaultUnit:        "%",
-		DefaultDeviceClass: "",
+		DefaultUnit:        "dBm",
+		DefaultDeviceClass: "signal_strength",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newWiFiSignalSensor(key, cfg)}, nil
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newWiFiSensor(key, cfg, wifiFieldSignal)}, nil
 		},
 	},
 	"wifi_ssid": {
@@ -150,8 +255,123 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newWiFiSSIDSensor(key, cfg)}, nil
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newWiFiSensor(key, cfg, wifiFieldSSID)}, nil
+		},
+	},
+	"wifi_bssid": {
+		DefaultName:        "Wi-Fi BSSID",
+		DefaultIcon:        "mdi:access-point",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "",
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newWiFiSensor(key, cfg, wifiFieldBSSID)}, nil
+		},
+	},
+	"wifi_frequency": {
+		DefaultName:        "Wi-Fi frequency",
+		DefaultIcon:        "mdi:sine-wave",
+		DefaultUnit:        "MHz",
+		DefaultDeviceClass: "frequency",
+		DefaultStateClass:  "measurement",
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return []Sensor{newWiFiSensor(key, cfg, wifiFieldFrequency)}, nil
+		},
+	},
+	"wifi_bitrate": {
+		DefaultName:        "Wi-Fi bitrate",
+		DefaultIcon:        "mdi:speedometer",
+		DefaultUnit:        "Mbit/s",
+		DefaultDeviceClass: "data_rate",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newWiFiBitrateSensors(key, cfg)
+		},
+	},
+
+	// Pressure stall information
+	"psi_cpu": {
+		DefaultName:        "CPU pressure",
+		DefaultIcon:        "mdi:gauge",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newPSISensors(key, cfg, "cpu")
+		},
+	},
+	"psi_memory": {
+		DefaultName:        "Memory pressure",
+		DefaultIcon:        "mdi:gauge",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newPSISensors(key, cfg, "memory")
+		},
+	},
+	"psi_io": {
+		DefaultName:        "I/O pressure",
+		DefaultIcon:        "mdi:gauge",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newPSISensors(key, cfg, "io")
+		},
+	},
+
+	// Services
+	"systemd_unit": {
+		DefaultName:        "Service",
+		DefaultIcon:        "mdi:cog-outline",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newSystemdUnitSensors(key, cfg, res)
+		},
+	},
+
+	"process": {
+		DefaultName:        "Process",
+		DefaultIcon:        "mdi:application-cog-outline",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newProcessSensors(key, cfg)
+		},
+	},
+
+	"container": {
+		DefaultName:        "Container",
+		DefaultIcon:        "mdi:docker",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newContainerSensors(key, cfg)
+		},
+	},
+
+	"cgroup": {
+		DefaultName:        "Cgroup",
+		DefaultIcon:        "mdi:file-tree-outline",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "measurement",
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newCgroupSensors(key, cfg)
 		},
 	},
 
@@ -162,8 +382,9 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "%",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newGPUUsageSensor(key, cfg)}, nil
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newGPUSensors(key, cfg, gpuMetricUsage, res)
 		},
 	},
 	"gpu_memory_usage": {
@@ -172,8 +393,9 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "%",
 		DefaultDeviceClass: "",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newGPUMemoryUsageSensor(key, cfg)}, nil
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newGPUSensors(key, cfg, gpuMetricMemoryUsage, res)
 		},
 	},
 	"gpu_temp": {
@@ -182,8 +404,9 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "°C",
 		DefaultDeviceClass: "temperature",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newGPUTempSensor(key, cfg)}, nil
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newGPUSensors(key, cfg, gpuMetricTemp, res)
 		},
 	},
 	"gpu_power": {
@@ -192,8 +415,67 @@ var registry = map[string]SensorDefinition{
 		DefaultUnit:        "W",
 		DefaultDeviceClass: "power",
 		DefaultStateClass:  "measurement",
-		Factory: func(key string, cfg config.SensorConfig) ([]Sensor, error) {
-			return []Sensor{newGPUPowerSensor(key, cfg)}, nil
+		FanOut:             fansOut,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newGPUSensors(key, cfg, gpuMetricPower, res)
+		},
+	},
+
+	// Custom
+	"command": {
+		DefaultName:        "Command",
+		DefaultIcon:        "mdi:console",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "",
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			s, err := newCommandSensor(key, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return []Sensor{s}, nil
+		},
+	},
+	"file": {
+		DefaultName:        "File",
+		DefaultIcon:        "mdi:file-document-outline",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "",
+		FanOut:             fansOutByGlob,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newFileSensors(key, cfg)
+		},
+	},
+	"http": {
+		DefaultName:        "HTTP",
+		DefaultIcon:        "mdi:web",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "",
+		FanOut:             fansOutByValues,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newHTTPSensors(key, cfg)
+		},
+	},
+	"prometheus": {
+		DefaultName:        "Prometheus",
+		DefaultIcon:        "mdi:chart-box-outline",
+		DefaultUnit:        "",
+		DefaultDeviceClass: "",
+		DefaultStateClass:  "",
+		FanOut:             fansOutByValues,
+		Factory: func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+			return newPrometheusSensors(key, cfg, res)
 		},
 	},
 }
+
+// fansOut is the FanOut of types creating one sensor per device, unit or metric.
+func fansOut(config.SensorConfig) bool { return true }
+
+// fansOutByValues is the FanOut of types creating one sensor per entry of values.
+func fansOutByValues(cfg config.SensorConfig) bool { return len(cfg.Values) > 0 }
+
+// fansOutByGlob is the FanOut of types creating one sensor per file matching path.
+func fansOutByGlob(cfg config.SensorConfig) bool { return strings.ContainsAny(cfg.Path, "*?[") }
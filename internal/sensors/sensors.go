@@ -4,9 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/systemd"
 )
 
 type Sensor interface {
@@ -14,20 +20,132 @@ type Sensor interface {
 	Name() string
 	Interval() time.Duration
 	HA() *config.HASensorConfig
-	Collect(ctx context.Context) (string, error)
+	// Precision is the configured number of decimals, or nil to use
+	// the default precision of each reading.
+	Precision() *int
+	PublishPolicy() PublishPolicy
+	Collect(ctx context.Context) (Reading, error)
+}
+
+// PublishPolicy controls when the agent republishes an unchanged
+// or slightly changed state.
+type PublishPolicy struct {
+	// MinChange and MinChangePercent suppress numeric changes smaller than
+	// the threshold, compared to the last published value.
+	MinChange        float64
+	MinChangePercent float64
+	// ForceUpdateEvery republishes the state even if it did not change.
+	ForceUpdateEvery time.Duration
+}
+
+// AttributesSensor is implemented by sensors that publish JSON attributes
+// alongside their state. The agent calls CollectWithAttributes instead of Collect.
+type AttributesSensor interface {
+	Sensor
+	CollectWithAttributes(ctx context.Context) (Reading, map[string]any, error)
+}
+
+// attributesCollector is implemented by sensors able to publish attributes,
+// which read their state and attributes at once. Collect drops the attributes.
+type attributesCollector interface {
+	Sensor
+	configurable
+	collect(ctx context.Context) (Reading, map[string]any, error)
+}
+
+// withAttributes makes an attributesCollector publish its attributes.
+// Factories wrap sensors in it when attributes are enabled.
+type withAttributes struct {
+	attributesCollector
+}
+
+func (s withAttributes) CollectWithAttributes(ctx context.Context) (Reading, map[string]any, error) {
+	return s.collect(ctx)
+}
+
+// binarySensor is implemented by sensors with ON/OFF readings.
+type binarySensor interface {
+	binary()
+}
+
+// Component returns the Home Assistant MQTT component used to announce s:
+// "binary_sensor" for sensors with ON/OFF readings, "sensor" otherwise.
+func Component(s Sensor) string {
+	if _, ok := s.(binarySensor); ok {
+		return "binary_sensor"
+	}
+	return "sensor"
 }
 
 type base struct {
-	key      string
-	name     string
-	interval time.Duration
-	ha       *config.HASensorConfig
+	key       string
+	name      string
+	interval  time.Duration
+	ha        *config.HASensorConfig
+	precision *int
+	pipeline  *pipeline
+	policy    PublishPolicy
+}
+
+func (b base) Key() string                  { return b.key }
+func (b base) Name() string                 { return b.name }
+func (b base) Interval() time.Duration      { return b.interval }
+func (b base) HA() *config.HASensorConfig   { return b.ha }
+func (b base) Precision() *int              { return b.precision }
+func (b base) PublishPolicy() PublishPolicy { return b.policy }
+
+// configurable is implemented by every sensor through the embedded base,
+// so Build applies options shared by all sensor types without threading
+// them through factories.
+type configurable interface {
+	configure(cfg config.SensorConfig, p *pipeline, unit string)
+	transform(r Reading) Reading
 }
 
-func (b base) Key() string                { return b.key }
-func (b base) Name() string               { return b.name }
-func (b base) Interval() time.Duration    { return b.interval }
-func (b base) HA() *config.HASensorConfig { return b.ha }
+func (b *base) configure(cfg config.SensorConfig, p *pipeline, unit string) {
+	b.precision = cfg.Precision
+	b.pipeline = p
+	b.policy = PublishPolicy{
+		MinChange:        cfg.MinChange,
+		MinChangePercent: cfg.MinChangePercent,
+		ForceUpdateEvery: cfg.ForceUpdateEvery,
+	}
+
+	if b.ha != nil && b.ha.Unit != unit {
+		ha := *b.ha
+		ha.Unit = unit
+		b.ha = &ha
+	}
+}
+
+func (b *base) transform(r Reading) Reading {
+	return b.pipeline.apply(r)
+}
+
+// Collect collects s, including JSON attributes when s is an AttributesSensor,
+// and applies the configured transforms. Numeric readings without a unit
+// get the unit advertised in discovery.
+func Collect(ctx context.Context, s Sensor) (Reading, map[string]any, error) {
+	var (
+		r     Reading
+		attrs map[string]any
+		err   error
+	)
+	if as, ok := s.(AttributesSensor); ok {
+		r, attrs, err = as.CollectWithAttributes(ctx)
+	} else {
+		r, err = s.Collect(ctx)
+	}
+
+	if c, ok := s.(configurable); ok {
+		r = c.transform(r)
+	}
+
+	if r.Kind == ReadingNumber && r.Unit == "" && s.HA() != nil {
+		r.Unit = s.HA().Unit
+	}
+	return r, attrs, err
+}
 
 type SensorDefinition struct {
 	DefaultName        string
@@ -35,7 +153,11 @@ type SensorDefinition struct {
 	DefaultUnit        string
 	DefaultDeviceClass string
 	DefaultStateClass  string
-	Factory            func(key string, cfg config.SensorConfig) ([]Sensor, error)
+	// FanOut reports whether an entry creates sensors keyed by its own key
+	// and a suffix (e.g. disk_usage_root) rather than by its key alone.
+	// Nil means it never does.
+	FanOut  func(cfg config.SensorConfig) bool
+	Factory func(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error)
 }
 
 func Prepare(cfg *config.Config) error {
@@ -50,9 +172,13 @@ func Prepare(cfg *config.Config) error {
 
 func Normalize(cfg *config.Config) error {
 	for sensorKey, sensorCfg := range cfg.Sensors {
-		def, ok := registry[sensorKey]
+		if sensorCfg.Type == "" {
+			sensorCfg.Type = sensorKey
+		}
+
+		def, ok := registry[sensorCfg.Type]
 		if !ok {
-			return errors.New("sensors: unknown sensor type: " + sensorKey)
+			return errors.New("sensors." + sensorKey + ": unknown sensor type: " + sensorCfg.Type)
 		}
 
 		if sensorCfg.Name == "" {
@@ -88,12 +214,16 @@ func Normalize(cfg *config.Config) error {
 
 func Validate(cfg config.Config) error {
 	for sensorKey, sensorCfg := range cfg.Sensors {
-		def, ok := registry[sensorKey]
+		if sensorCfg.Type == "" {
+			return errors.New("sensors." + sensorKey + ": type is empty (run Normalize first)")
+		}
+
+		def, ok := registry[sensorCfg.Type]
 		if !ok {
-			return errors.New("sensors: unknown sensor type: " + sensorKey)
+			return errors.New("sensors." + sensorKey + ": unknown sensor type: " + sensorCfg.Type)
 		}
 		if def.Factory == nil {
-			return errors.New("sensors." + sensorKey + ": no Factory implementation")
+			return errors.New("sensors." + sensorKey + ": no Factory implementation for type " + sensorCfg.Type)
 		}
 
 		if sensorCfg.Name == "" {
@@ -104,22 +234,89 @@ func Validate(cfg config.Config) error {
 		}
 	}
 
-	return nil
+	return validateKeys(cfg.Sensors)
 }
 
-func Build(cfg config.Config) ([]Sensor, error) {
-	out := make([]Sensor, 0, len(cfg.Sensors))
+// validateKeys rejects entries whose generated keys may collide. Generated
+// keys become MQTT topics and Home Assistant unique IDs, so a fan-out key of
+// one entry (e.g. disk_usage_root) must not shadow the key of another entry.
+func validateKeys(sc map[string]config.SensorConfig) error {
+	keys := slices.Sorted(maps.Keys(sc))
 
-	for key, scfg := range cfg.Sensors {
-		def, ok := registry[key]
-		if !ok {
-			return nil, fmt.Errorf("sensors: unknown sensor type: %s", key)
+	for _, key := range keys {
+		scfg := sc[key]
+		if fanOut := registry[scfg.Type].FanOut; fanOut == nil || !fanOut(scfg) {
+			continue
 		}
-		if def.Factory == nil {
-			return nil, fmt.Errorf("sensors: %s has no Factory (not implemented)", key)
+		for _, other := range keys {
+			if strings.HasPrefix(other, key+"_") {
+				return fmt.Errorf("sensors.%s: key may collide with keys generated by sensors.%s (type %s); rename one of them", other, key, scfg.Type)
+			}
 		}
+	}
+	return nil
+}
+
+// Set holds the sensors built from one configuration and the resources
+// they share, such as the NVML session or the systemd connection.
+type Set struct {
+	Sensors []Sensor
+	res     *resources
+}
+
+// Close releases the resources shared by the sensors of the set.
+func (s *Set) Close() {
+	s.res.close()
+}
+
+// resources are shared by the sensors of one Build: sessions opened on
+// first use, and caches shared by sensors reading the same device or
+// endpoint in one interval group.
+type resources struct {
+	nvml         *nvmlSession
+	systemd      *systemdConn
+	gpuSnapshots map[gpuSnapshotKey]*gpuSnapshotCache
+	promScrapes  map[promScrapeKey]*promScrape
+}
+
+func newResources() *resources {
+	return &resources{
+		nvml:         &nvmlSession{},
+		systemd:      &systemdConn{connect: systemd.Connect},
+		gpuSnapshots: make(map[gpuSnapshotKey]*gpuSnapshotCache),
+		promScrapes:  make(map[promScrapeKey]*promScrape),
+	}
+}
+
+func (r *resources) close() {
+	r.nvml.shutdown()
+	r.systemd.close()
+}
 
-		list, err := def.Factory(key, scfg)
+func Build(cfg config.Config) (*Set, error) {
+	res := newResources()
+	list, err := build(cfg, res)
+	if err != nil {
+		// Sessions opened by factories, e.g. to list GPUs, are closed here.
+		res.close()
+		return nil, err
+	}
+	return &Set{Sensors: list, res: res}, nil
+}
+
+func build(cfg config.Config, res *resources) ([]Sensor, error) {
+	out := make([]Sensor, 0, len(cfg.Sensors))
+
+	keys := make([]string, 0, len(cfg.Sensors))
+	for key := range cfg.Sensors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		scfg := cfg.Sensors[key]
+
+		list, err := registry[scfg.Type].Factory(key, scfg, res)
 		if err != nil {
 			return nil, fmt.Errorf("sensors: %s: %w", key, err)
 		}
@@ -127,8 +324,260 @@ func Build(cfg config.Config) ([]Sensor, error) {
 			return nil, fmt.Errorf("sensors: %s factory returned 0 sensors", key)
 		}
 
+		if len(scfg.Transforms) > 0 {
+			if err := checkSingleUnit(list); err != nil {
+				return nil, fmt.Errorf("sensors: %s: transforms: %w", key, err)
+			}
+		}
+
+		// Keys of different entries are checked by Validate; a fan-out may
+		// still generate one key twice, e.g. for mounts sanitized alike.
+		seen := make(map[string]struct{}, len(list))
+		for _, s := range list {
+			if _, ok := s.(AttributesSensor); scfg.Attributes && !ok {
+				return nil, fmt.Errorf("sensors: %s: attributes are not supported by type %s", key, scfg.Type)
+			}
+
+			if c, ok := s.(configurable); ok {
+				var unit string
+				if ha := s.HA(); ha != nil {
+					unit = ha.Unit
+				}
+
+				p, unit, err := newPipeline(scfg.Transforms, unit)
+				if err != nil {
+					return nil, fmt.Errorf("sensors: %s: %w", key, err)
+				}
+				c.configure(scfg, p, unit)
+			}
+
+			if _, ok := seen[s.Key()]; ok {
+				return nil, fmt.Errorf("sensors: %s generates duplicate key %s", key, s.Key())
+			}
+			seen[s.Key()] = struct{}{}
+		}
+
 		out = append(out, list...)
 	}
 
 	return out, nil
 }
+
+// checkSingleUnit returns an error unless all sensors of a fan-out publish
+// in one unit, so one list of transforms fits every generated sensor.
+func checkSingleUnit(list []Sensor) error {
+	var units []string
+	for _, s := range list {
+		var unit string
+		if ha := s.HA(); ha != nil {
+			unit = ha.Unit
+		}
+		if !slices.Contains(units, unit) {
+			units = append(units, unit)
+		}
+	}
+	if len(units) > 1 {
+		for i, u := range units {
+			if u == "" {
+				units[i] = "none"
+			}
+		}
+		return fmt.Errorf("generated sensors have different units (%s); use metrics to keep one unit per entry", strings.Join(units, ", "))
+	}
+	return nil
+}
+
+// selectMetrics returns the requested metrics in the order of available,
+// or all available metrics when none were requested.
+func selectMetrics(requested []string, available []string) ([]string, error) {
+	if len(requested) == 0 {
+		return available, nil
+	}
+
+	want := make(map[string]struct{}, len(requested))
+	for _, m := range requested {
+		if !slices.Contains(available, m) {
+			return nil, fmt.Errorf("unknown metric %q (supported: %s)", m, strings.Join(available, ", "))
+		}
+		want[m] = struct{}{}
+	}
+
+	out := make([]string, 0, len(want))
+	for _, m := range available {
+		if _, ok := want[m]; ok {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// haWithDefaults returns a copy of ha with per-entity defaults applied
+// to fields the user did not override. It is used by sensors whose
+// fan-out entities measure different quantities.
+func haWithDefaults(ha *config.HASensorConfig, unit, deviceClass string) *config.HASensorConfig {
+	out := config.HASensorConfig{}
+	if ha != nil {
+		out = *ha
+	}
+	if out.Unit == "" {
+		out.Unit = unit
+	}
+	if out.DeviceClass == "" {
+		out.DeviceClass = deviceClass
+	}
+	return &out
+}
+
+// haWithStateClass returns a copy of ha with stateClass applied unless the
+// user set one. It is used by fan-out entities of which only some are
+// numeric measurements.
+func haWithStateClass(ha *config.HASensorConfig, stateClass string) *config.HASensorConfig {
+	out := config.HASensorConfig{}
+	if ha != nil {
+		out = *ha
+	}
+	if out.StateClass == "" {
+		out.StateClass = stateClass
+	}
+	return &out
+}
+
+// haWithOverrides returns a copy of ha with the non-empty fields of override
+// applied. It is used by fan-out entities configured one by one.
+func haWithOverrides(ha, override *config.HASensorConfig) *config.HASensorConfig {
+	if override == nil {
+		return ha
+	}
+
+	out := config.HASensorConfig{}
+	if ha != nil {
+		out = *ha
+	}
+	if override.Icon != "" {
+		out.Icon = override.Icon
+	}
+	if override.Unit != "" {
+		out.Unit = override.Unit
+	}
+	if override.DeviceClass != "" {
+		out.DeviceClass = override.DeviceClass
+	}
+	if override.StateClass != "" {
+		out.StateClass = override.StateClass
+	}
+	if override.ExpireAfter != 0 {
+		out.ExpireAfter = override.ExpireAfter
+	}
+	return &out
+}
+
+// counterWindow holds two consecutive reads of monotonic counters. Rate
+// sensors built from one configuration share the reads, so all their values
+// cover the same window.
+type counterWindow[T any] struct {
+	prev, cur T
+	// elapsed is the time between the two reads, or zero when there is
+	// no previous read to compare to.
+	elapsed time.Duration
+}
+
+// newCounterCache returns a tickCache of counter windows over read. With
+// baseline set, the counters are read right away and the first collection
+// waits until one interval has passed, so the first value covers a whole
+// interval like every later one. A failed baseline read leaves the first
+// window without a previous read.
+func newCounterCache[T any](interval time.Duration, baseline bool, read func(ctx context.Context) (T, error)) *tickCache[counterWindow[T]] {
+	var (
+		last    T
+		lastAt  time.Time
+		hasLast bool
+	)
+	if baseline {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if v, err := read(ctx); err == nil {
+			last, lastAt, hasLast = v, time.Now(), true
+		}
+		cancel()
+	}
+
+	first := hasLast
+	return newTickCache(interval, func(ctx context.Context) (counterWindow[T], error) {
+		if first {
+			if err := sleepUntil(ctx, lastAt.Add(interval)); err != nil {
+				return counterWindow[T]{}, err
+			}
+			first = false
+		}
+
+		v, err := read(ctx)
+		if err != nil {
+			return counterWindow[T]{}, err
+		}
+
+		now := time.Now()
+		w := counterWindow[T]{cur: v}
+		if hasLast {
+			w.prev, w.elapsed = last, now.Sub(lastAt)
+		}
+		last, lastAt, hasLast = v, now, true
+		return w, nil
+	})
+}
+
+// perSecond returns delta spread over the window, or false when there is
+// no previous read.
+func (w counterWindow[T]) perSecond(delta float64) (float64, bool) {
+	if w.elapsed <= 0 {
+		return 0, false
+	}
+	return delta / w.elapsed.Seconds(), true
+}
+
+// sleepUntil waits until t or until ctx is done.
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tickCache shares a value read once per tick between the sensors of one
+// interval group. Sensors of a group are collected back to back on each tick,
+// so a value younger than half the interval belongs to the current tick.
+type tickCache[T any] struct {
+	mu     sync.Mutex
+	maxAge time.Duration
+	read   func(ctx context.Context) (T, error)
+
+	taken time.Time
+	value T
+	err   error
+}
+
+func newTickCache[T any](interval time.Duration, read func(ctx context.Context) (T, error)) *tickCache[T] {
+	return &tickCache[T]{maxAge: interval / 2, read: read}
+}
+
+// get returns the value of the current tick and the time it was read.
+func (c *tickCache[T]) get(ctx context.Context) (T, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.taken.IsZero() && time.Since(c.taken) < c.maxAge {
+		return c.value, c.taken, c.err
+	}
+
+	c.value, c.err = c.read(ctx)
+	c.taken = time.Now()
+	return c.value, c.taken, c.err
+}
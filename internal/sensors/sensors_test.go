@@ -0,0 +1,178 @@
+package sensors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+// writeFile writes data to path, creating parent directories.
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// useFakeSysfs points sensors at an empty sysfs tree for the duration
+// of the test and returns its root.
+func useFakeSysfs(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	prev := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = prev })
+	return root
+}
+
+func TestNormalizeDefaultsType(t *testing.T) {
+	cfg := config.Config{
+		MQTT: config.MQTTConfig{DefaultInterval: time.Minute},
+		Sensors: map[string]config.SensorConfig{
+			"memory_usage": {},
+			"root_disk":    {Type: "disk_usage", IncludeMounts: []string{"/"}},
+		},
+	}
+	if err := Prepare(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	mem := cfg.Sensors["memory_usage"]
+	if mem.Type != "memory_usage" || mem.Name != registry["memory_usage"].DefaultName || mem.Interval != time.Minute {
+		t.Errorf("memory_usage = %+v, want type, name and interval defaulted", mem)
+	}
+	if got := cfg.Sensors["root_disk"].Type; got != "disk_usage" {
+		t.Errorf("root_disk type = %q, want disk_usage", got)
+	}
+}
+
+func TestPrepareErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sensors map[string]config.SensorConfig
+		wantErr string
+	}{
+		{
+			name:    "key without type is not a type",
+			sensors: map[string]config.SensorConfig{"my_memory": {}},
+			wantErr: "unknown sensor type: my_memory",
+		},
+		{
+			name:    "unknown type",
+			sensors: map[string]config.SensorConfig{"ram": {Type: "ram_usage"}},
+			wantErr: "unknown sensor type: ram_usage",
+		},
+		{
+			// disk_usage generates disk_usage_root for the root mount.
+			name: "fan-out prefix",
+			sensors: map[string]config.SensorConfig{
+				"disk_usage":      {IncludeMounts: []string{"/"}},
+				"disk_usage_root": {Type: "memory_usage"},
+			},
+			wantErr: "sensors.disk_usage_root: key may collide with keys generated by sensors.disk_usage",
+		},
+		{
+			name: "glob fan out",
+			sensors: map[string]config.SensorConfig{
+				"thermal":       {Type: "file", Path: "/sys/class/thermal/thermal_zone*/temp"},
+				"thermal_zone0": {Type: "uptime"},
+			},
+			wantErr: "sensors.thermal_zone0: key may collide",
+		},
+		{
+			name: "values fan out",
+			sensors: map[string]config.SensorConfig{
+				"api":        {Type: "http", URL: "http://localhost", Values: map[string]config.SensorValueConfig{"up": {}}},
+				"api_uptime": {Type: "uptime"},
+			},
+			wantErr: "sensors.api_uptime: key may collide",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{MQTT: config.MQTTConfig{DefaultInterval: time.Minute}, Sensors: tt.sensors}
+			err := Prepare(&cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrepareAllowsSingleKeyPrefix(t *testing.T) {
+	// Without values an http entry creates only its own key.
+	cfg := config.Config{
+		MQTT: config.MQTTConfig{DefaultInterval: time.Minute},
+		Sensors: map[string]config.SensorConfig{
+			"api":        {Type: "http", URL: "http://localhost"},
+			"api_uptime": {Type: "uptime"},
+		},
+	}
+	if err := Prepare(&cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildRejectsDuplicateKeys(t *testing.T) {
+	// Both mounts sanitize to mnt_a.
+	cfg := config.Config{
+		MQTT: config.MQTTConfig{DefaultInterval: time.Minute},
+		Sensors: map[string]config.SensorConfig{
+			"disk_usage": {IncludeMounts: []string{"/mnt-a", "/mnt_a"}},
+		},
+	}
+	if err := Prepare(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Build(cfg); err == nil || !strings.Contains(err.Error(), "duplicate key disk_usage_mnt_a") {
+		t.Errorf("got %v, want a duplicate key error", err)
+	}
+}
+
+func TestCounterCache(t *testing.T) {
+	const interval = 50 * time.Millisecond
+
+	var n int
+	read := func(context.Context) (int, error) {
+		n++
+		return n * 10, nil
+	}
+
+	c := newCounterCache(interval, true, read)
+	if n != 1 {
+		t.Fatalf("got %d baseline reads, want 1", n)
+	}
+
+	start := time.Now()
+	w, _, err := c.get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first window covers a full interval from the baseline.
+	if w.prev != 10 || w.cur != 20 || w.elapsed < interval || time.Since(start) > interval*4 {
+		t.Errorf("first window = %+v after %s, want 10 -> 20 over at least %s", w, time.Since(start), interval)
+	}
+	if rate, ok := w.perSecond(float64(w.cur - w.prev)); !ok || rate <= 0 || rate > 10/interval.Seconds() {
+		t.Errorf("perSecond = %g, %v", rate, ok)
+	}
+
+	// Without a baseline the first window has nothing to compare to.
+	c = newCounterCache(interval, false, read)
+	w, _, err = c.get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.perSecond(1); ok || w.cur != 30 {
+		t.Errorf("window without baseline = %+v, want no rate", w)
+	}
+}
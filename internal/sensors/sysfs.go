@@ -0,0 +1,50 @@
+package sensors
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysfsRoot is the mount point of sysfs. Sensors build their paths from it
+// so they can be pointed at a fake tree.
+var sysfsRoot = "/sys"
+
+func sysfsPath(elem ...string) string {
+	return filepath.Join(append([]string{sysfsRoot}, elem...)...)
+}
+
+func readSysfsString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readSysfsUint(path string) (uint64, error) {
+	s, err := readSysfsString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func readSysfsInt(path string) (int64, error) {
+	s, err := readSysfsString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// linkBase returns the last element of the path a sysfs symlink points to,
+// e.g. the PCI address of a device or the name of its driver.
+func linkBase(path string) (string, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/Miklakapi/gometrum/internal/config"
 	"github.com/shirou/gopsutil/v4/host"
@@ -15,17 +16,32 @@ type uptimeSensor struct {
 }
 
 func newUptimeSensor(key string, cfg config.SensorConfig) Sensor {
-	return &uptimeSensor{
+	s := &uptimeSensor{
 		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
 	}
+	if cfg.Attributes {
+		return withAttributes{s}
+	}
+	return s
+}
+
+func (s *uptimeSensor) Collect(ctx context.Context) (Reading, error) {
+	r, _, err := s.collect(ctx)
+	return r, err
 }
 
-func (s *uptimeSensor) Collect(ctx context.Context) (string, error) {
-	u, err := host.Uptime()
+// collect derives the uptime from the boot time, also returned as an attribute.
+func (s *uptimeSensor) collect(ctx context.Context) (Reading, map[string]any, error) {
+	bt, err := host.BootTimeWithContext(ctx)
 	if err != nil {
-		return "unavailable", err
+		return unavailable(), nil, fmt.Errorf("uptime: %w", err)
 	}
-	return fmt.Sprintf("%d", u), nil
+
+	boot := time.Unix(int64(bt), 0)
+	attrs := map[string]any{
+		"boot_time": boot.UTC().Format(time.RFC3339),
+	}
+	return number(time.Since(boot).Truncate(time.Second).Seconds(), 0), attrs, nil
 }
 
 type osVersionSensor struct {
@@ -33,29 +49,53 @@ type osVersionSensor struct {
 }
 
 func newOSVersionSensor(key string, cfg config.SensorConfig) Sensor {
-	return &osVersionSensor{
+	s := &osVersionSensor{
 		base: base{key: key, name: cfg.Name, interval: cfg.Interval, ha: cfg.HA},
 	}
+	if cfg.Attributes {
+		return withAttributes{s}
+	}
+	return s
 }
 
-func (s *osVersionSensor) Collect(ctx context.Context) (string, error) {
-	hi, err := host.Info()
+func (s *osVersionSensor) Collect(ctx context.Context) (Reading, error) {
+	r, _, err := s.collect(ctx)
+	return r, err
+}
+
+// collect also returns kernel, architecture and platform details as attributes.
+func (s *osVersionSensor) collect(ctx context.Context) (Reading, map[string]any, error) {
+	hi, err := host.InfoWithContext(ctx)
 	if err != nil {
-		return "unavailable", err
+		return unavailable(), nil, err
 	}
 
+	val, err := osVersion(hi)
+	attrs := map[string]any{
+		"kernel":           hi.KernelVersion,
+		"arch":             hi.KernelArch,
+		"os":               hi.OS,
+		"platform":         hi.Platform,
+		"platform_family":  hi.PlatformFamily,
+		"platform_version": hi.PlatformVersion,
+		"virtualization":   hi.VirtualizationSystem,
+	}
+	return val, attrs, err
+}
+
+func osVersion(hi *host.InfoStat) (Reading, error) {
 	if hi.Platform != "" && hi.PlatformVersion != "" {
-		return fmt.Sprintf("%s %s", hi.Platform, hi.PlatformVersion), nil
+		return text(hi.Platform + " " + hi.PlatformVersion), nil
 	}
 	if hi.OS != "" && hi.KernelVersion != "" {
-		return fmt.Sprintf("%s %s", hi.OS, hi.KernelVersion), nil
+		return text(hi.OS + " " + hi.KernelVersion), nil
 	}
 
 	if hi.KernelVersion != "" {
-		return hi.KernelVersion, nil
+		return text(hi.KernelVersion), nil
 	}
 
-	return "unavailable", errors.New("empty host info")
+	return unavailable(), errors.New("empty host info")
 }
 
 type hostnameSensor struct {
@@ -68,13 +108,13 @@ func newHostnameSensor(key string, cfg config.SensorConfig) Sensor {
 	}
 }
 
-func (s *hostnameSensor) Collect(ctx context.Context) (string, error) {
+func (s *hostnameSensor) Collect(ctx context.Context) (Reading, error) {
 	h, err := os.Hostname()
 	if err != nil {
-		return "unavailable", err
+		return unavailable(), err
 	}
 	if h == "" {
-		return "unavailable", errors.New("empty hostname")
+		return unavailable(), errors.New("empty hostname")
 	}
-	return h, nil
+	return text(h), nil
 }
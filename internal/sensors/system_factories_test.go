@@ -0,0 +1,41 @@
+package sensors
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestUptimeAttributes(t *testing.T) {
+	plain := newUptimeSensor("uptime", config.SensorConfig{Interval: time.Minute})
+	if _, ok := plain.(AttributesSensor); ok {
+		t.Error("attributes published without attributes: true")
+	}
+
+	s := newUptimeSensor("uptime", config.SensorConfig{Interval: time.Minute, Attributes: true})
+	as, ok := s.(AttributesSensor)
+	if !ok {
+		t.Fatal("attributes: true does not publish attributes")
+	}
+	// Options shared by all sensors still apply to the wrapped sensor.
+	if _, ok := s.(configurable); !ok {
+		t.Fatal("wrapped sensor is not configurable")
+	}
+
+	r, attrs, err := as.CollectWithAttributes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	boot, err := time.Parse(time.RFC3339, attrs["boot_time"].(string))
+	if err != nil {
+		t.Fatalf("boot_time: %v", err)
+	}
+
+	// State and attribute come from the same read.
+	if want := time.Since(boot).Seconds(); r.Kind != ReadingNumber || math.Abs(r.Value-want) > 2 {
+		t.Errorf("uptime = %+v, want about %.0f s after boot_time %s", r, want, boot)
+	}
+}
@@ -0,0 +1,148 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/keys"
+	"github.com/Miklakapi/gometrum/internal/systemd"
+)
+
+const (
+	systemdActiveState = "active_state"
+	systemdSubState    = "sub_state"
+	systemdRestarts    = "restarts"
+	systemdSince       = "since"
+)
+
+var systemdUnitMetrics = []string{systemdActiveState, systemdSubState, systemdRestarts, systemdSince}
+
+var systemdUnitMetricNames = map[string]string{
+	systemdActiveState: "state",
+	systemdSubState:    "sub-state",
+	systemdRestarts:    "restarts",
+	systemdSince:       "since",
+}
+
+type systemdUnitSensor struct {
+	base
+	unit   string
+	status *tickCache[systemd.UnitStatus]
+	metric string
+}
+
+func newSystemdUnitSensors(key string, cfg config.SensorConfig, res *resources) ([]Sensor, error) {
+	if len(cfg.Units) == 0 {
+		return nil, errors.New("units must list at least one unit (e.g. nginx.service)")
+	}
+
+	metrics, err := selectMetrics(cfg.Metrics, systemdUnitMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	units := append([]string(nil), cfg.Units...)
+	sort.Strings(units)
+
+	out := make([]Sensor, 0, len(units)*len(metrics))
+	for _, u := range units {
+		unit := systemd.UnitName(u)
+		short := strings.TrimSuffix(unit, ".service")
+		status := newTickCache(cfg.Interval, func(ctx context.Context) (systemd.UnitStatus, error) {
+			m, err := res.systemd.manager()
+			if err != nil {
+				return systemd.UnitStatus{}, err
+			}
+			return m.UnitStatus(ctx, unit)
+		})
+
+		for _, m := range metrics {
+			sKey := key + "_" + keys.Sanitize(short) + "_" + m
+			sName := fmt.Sprintf("%s %s %s", cfg.Name, short, systemdUnitMetricNames[m])
+
+			ha := cfg.HA
+			if m == systemdSince {
+				ha = haWithDefaults(cfg.HA, "", "timestamp")
+			}
+
+			out = append(out, &systemdUnitSensor{
+				base:   base{key: sKey, name: sName, interval: cfg.Interval, ha: ha},
+				unit:   unit,
+				status: status,
+				metric: m,
+			})
+		}
+	}
+
+	return out, nil
+}
+
+func (s *systemdUnitSensor) Collect(ctx context.Context) (Reading, error) {
+	st, _, err := s.status.get(ctx)
+	if err != nil {
+		return unavailable(), fmt.Errorf("systemd_unit(%s): %w", s.unit, err)
+	}
+
+	switch s.metric {
+	case systemdActiveState:
+		return text(st.ActiveState), nil
+	case systemdSubState:
+		return text(st.SubState), nil
+	case systemdRestarts:
+		// Only services count restarts.
+		if !st.HasRestarts {
+			return unavailable(), nil
+		}
+		return number(float64(st.Restarts), 0), nil
+	case systemdSince:
+		if st.StateChange.IsZero() {
+			return unavailable(), nil
+		}
+		return timestamp(st.StateChange), nil
+	default:
+		return unavailable(), fmt.Errorf("systemd_unit: unknown metric %q", s.metric)
+	}
+}
+
+// systemdConn is the systemd connection shared by unit sensors. It is opened
+// on first use and reopened after the bus connection was lost.
+type systemdConn struct {
+	mu      sync.Mutex
+	connect func() (systemd.Manager, error)
+	m       systemd.Manager
+}
+
+func (c *systemdConn) manager() (systemd.Manager, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.m != nil && c.m.Connected() {
+		return c.m, nil
+	}
+	if c.m != nil {
+		_ = c.m.Close()
+		c.m = nil
+	}
+
+	m, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	c.m = m
+	return m, nil
+}
+
+func (c *systemdConn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.m != nil {
+		_ = c.m.Close()
+		c.m = nil
+	}
+}
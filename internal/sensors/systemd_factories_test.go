@@ -0,0 +1,139 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/systemd"
+)
+
+// fakeSystemd serves unit states from a map.
+type fakeSystemd struct {
+	units     map[string]systemd.UnitStatus
+	calls     int
+	connected bool
+}
+
+func (f *fakeSystemd) UnitStatus(_ context.Context, unit string) (systemd.UnitStatus, error) {
+	f.calls++
+	st, ok := f.units[unit]
+	if !ok {
+		return systemd.UnitStatus{}, errors.New("unexpected unit " + unit)
+	}
+	return st, nil
+}
+
+func (f *fakeSystemd) StartUnit(context.Context, string) (string, error)   { return "", nil }
+func (f *fakeSystemd) StopUnit(context.Context, string) (string, error)    { return "", nil }
+func (f *fakeSystemd) RestartUnit(context.Context, string) (string, error) { return "", nil }
+func (f *fakeSystemd) Connected() bool                                     { return f.connected }
+func (f *fakeSystemd) Close() error                                        { return nil }
+
+// fakeSystemdResources returns resources whose unit sensors connect
+// through connect.
+func fakeSystemdResources(t *testing.T, connect func() (systemd.Manager, error)) *resources {
+	t.Helper()
+
+	res := newResources()
+	res.systemd.connect = connect
+	t.Cleanup(res.close)
+	return res
+}
+
+func TestSystemdUnitSensors(t *testing.T) {
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := &fakeSystemd{
+		connected: true,
+		units: map[string]systemd.UnitStatus{
+			"nginx.service": {
+				Name: "nginx.service", LoadState: "loaded", ActiveState: "active", SubState: "running",
+				Restarts: 2, HasRestarts: true, StateChange: since,
+			},
+			"backup.timer": {
+				Name: "backup.timer", LoadState: "loaded", ActiveState: "active", SubState: "waiting",
+			},
+			"gone.service": {
+				Name: "gone.service", LoadState: "not-found", ActiveState: "inactive", SubState: "dead",
+			},
+		},
+	}
+	res := fakeSystemdResources(t, func() (systemd.Manager, error) { return fake, nil })
+
+	got, err := newSystemdUnitSensors("svc", config.SensorConfig{
+		Name:     "Service",
+		Interval: time.Minute,
+		Units:    []string{"nginx", "backup.timer", "gone"},
+	}, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Reading{
+		"svc_backup_timer_active_state": text("active"),
+		"svc_backup_timer_sub_state":    text("waiting"),
+		"svc_backup_timer_restarts":     unavailable(),
+		"svc_backup_timer_since":        unavailable(),
+		"svc_gone_active_state":         text("inactive"),
+		"svc_gone_sub_state":            text("dead"),
+		"svc_gone_restarts":             unavailable(),
+		"svc_gone_since":                unavailable(),
+		"svc_nginx_active_state":        text("active"),
+		"svc_nginx_sub_state":           text("running"),
+		"svc_nginx_restarts":            number(2, 0),
+		"svc_nginx_since":               timestamp(since),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d sensors, want %d", len(got), len(want))
+	}
+	for _, s := range got {
+		r, err := s.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", s.Key(), err)
+		}
+		if r != want[s.Key()] {
+			t.Errorf("%s = %+v, want %+v", s.Key(), r, want[s.Key()])
+		}
+	}
+
+	// One status request per unit and tick.
+	if fake.calls != 3 {
+		t.Errorf("got %d status requests, want 3", fake.calls)
+	}
+}
+
+func TestSystemdManagerReconnects(t *testing.T) {
+	var conns []*fakeSystemd
+	failing := true
+	conn := &systemdConn{connect: func() (systemd.Manager, error) {
+		if failing {
+			return nil, errors.New("no system bus")
+		}
+		f := &fakeSystemd{connected: true}
+		conns = append(conns, f)
+		return f, nil
+	}}
+
+	if _, err := conn.manager(); err == nil {
+		t.Fatal("expected the connect error")
+	}
+
+	failing = false
+	m, err := conn.manager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := conn.manager(); again != m {
+		t.Error("a live connection was not reused")
+	}
+
+	conns[0].connected = false
+	if again, _ := conn.manager(); again == m {
+		t.Error("a lost connection was reused")
+	}
+	if len(conns) != 2 {
+		t.Errorf("got %d connections, want 2", len(conns))
+	}
+}
@@ -0,0 +1,95 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+	"github.com/Miklakapi/gometrum/internal/keys"
+)
+
+// temperatureSensor reports a single hwmon temperature input.
+type temperatureSensor struct {
+	base
+	chip   string
+	device string
+	label  string
+}
+
+func newTemperatureSensors(key string, cfg config.SensorConfig) ([]Sensor, error) {
+	if err := validateTempOptions(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Aggregate != "" {
+		return nil, errors.New("aggregate is not supported (one sensor is created per input; use cpu_temp to aggregate)")
+	}
+
+	temps, err := listHwmonTemps()
+	if err != nil {
+		return nil, fmt.Errorf("temperature: list hwmon failed: %w", err)
+	}
+
+	filter := hwmonFilter{chip: cfg.Chip, label: cfg.Label}
+
+	matched := make([]hwmonTemp, 0, len(temps))
+	chipDevices := make(map[string]map[string]struct{})
+	for _, t := range temps {
+		if !filter.match(t) {
+			continue
+		}
+		matched = append(matched, t)
+
+		if chipDevices[t.chip] == nil {
+			chipDevices[t.chip] = make(map[string]struct{})
+		}
+		chipDevices[t.chip][t.device] = struct{}{}
+	}
+
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("temperature: no hwmon input matches chip=%q label=%q", cfg.Chip, cfg.Label)
+	}
+
+	out := make([]Sensor, 0, len(matched))
+	for _, t := range matched {
+		id := t.chip + " " + t.label
+		// Chips present more than once (e.g. one per NVMe drive) are told
+		// apart by their device.
+		if len(chipDevices[t.chip]) > 1 {
+			id = t.chip + " " + t.device + " " + t.label
+		}
+
+		sKey := key + "_" + keys.Sanitize(id)
+		sName := fmt.Sprintf("%s %s", cfg.Name, id)
+
+		out = append(out, &temperatureSensor{
+			base:   base{key: sKey, name: sName, interval: cfg.Interval, ha: cfg.HA},
+			chip:   t.chip,
+			device: t.device,
+			label:  t.label,
+		})
+	}
+
+	return out, nil
+}
+
+func (s *temperatureSensor) Collect(ctx context.Context) (Reading, error) {
+	temps, err := listHwmonTemps()
+	if err != nil {
+		return unavailable(), fmt.Errorf("temperature: %w", err)
+	}
+
+	for _, t := range temps {
+		if t.chip != s.chip || t.device != s.device || t.label != s.label {
+			continue
+		}
+
+		v, err := readHwmonTemp(t)
+		if err != nil {
+			return unavailable(), fmt.Errorf("temperature: %w", err)
+		}
+		return number(v, 1), nil
+	}
+
+	return unavailable(), fmt.Errorf("temperature: %s %s not found", s.chip, s.label)
+}
@@ -0,0 +1,216 @@
+package sensors
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+// pipeline post-processes the numeric readings of one sensor. Steps run in
+// the configured order; smoothing steps keep their window between collections,
+// so every generated sensor gets its own pipeline.
+type pipeline struct {
+	steps []func(r *Reading)
+}
+
+// newPipeline builds the steps for a sensor publishing values in unit
+// and returns the unit of the transformed values.
+func newPipeline(transforms []config.TransformConfig, unit string) (*pipeline, string, error) {
+	if len(transforms) == 0 {
+		return nil, unit, nil
+	}
+
+	p := &pipeline{steps: make([]func(r *Reading), 0, len(transforms))}
+	for i, t := range transforms {
+		step, out, err := newTransformStep(t, unit)
+		if err != nil {
+			return nil, "", fmt.Errorf("transforms[%d]: %w", i, err)
+		}
+		p.steps = append(p.steps, step)
+		unit = out
+	}
+	return p, unit, nil
+}
+
+func (p *pipeline) apply(r Reading) Reading {
+	if p == nil || r.Kind != ReadingNumber {
+		return r
+	}
+	for _, step := range p.steps {
+		step(&r)
+	}
+	return r
+}
+
+func newTransformStep(t config.TransformConfig, unit string) (func(r *Reading), string, error) {
+	switch {
+	case t.Scale != nil:
+		f := *t.Scale
+		return func(r *Reading) { r.Value *= f }, unit, nil
+
+	case t.Offset != nil:
+		o := *t.Offset
+		return func(r *Reading) { r.Value += o }, unit, nil
+
+	case t.Convert != "":
+		conv, err := unitConversion(unit, t.Convert)
+		if err != nil {
+			return nil, "", err
+		}
+		to := t.Convert
+		// Converting to a larger unit (e.g. B to GiB) keeps the resolution
+		// of the source value, with at least two decimals.
+		extra := 0
+		if scale := math.Abs(conv(1) - conv(0)); scale < 1 {
+			extra = int(math.Ceil(-math.Log10(scale)))
+		}
+		return func(r *Reading) {
+			r.Value = conv(r.Value)
+			r.Unit = to
+			r.Precision = min(r.Precision+extra, max(r.Precision, 2))
+		}, to, nil
+
+	case t.Min != nil || t.Max != nil:
+		lo, hi := math.Inf(-1), math.Inf(1)
+		if t.Min != nil {
+			lo = *t.Min
+		}
+		if t.Max != nil {
+			hi = *t.Max
+		}
+		return func(r *Reading) { r.Value = min(max(r.Value, lo), hi) }, unit, nil
+
+	case t.Round != nil:
+		n := *t.Round
+		pow := math.Pow10(n)
+		return func(r *Reading) {
+			r.Value = math.Round(r.Value*pow) / pow
+			r.Precision = n
+		}, unit, nil
+
+	case t.Average > 0:
+		w := &sampleWindow{size: t.Average}
+		return func(r *Reading) {
+			var sum float64
+			for _, v := range w.add(r.Value) {
+				sum += v
+			}
+			r.Value = sum / float64(len(w.samples))
+		}, unit, nil
+
+	case t.Median > 0:
+		w := &sampleWindow{size: t.Median}
+		return func(r *Reading) {
+			sorted := slices.Sorted(slices.Values(w.add(r.Value)))
+			mid := len(sorted) / 2
+			if len(sorted)%2 == 0 {
+				r.Value = (sorted[mid-1] + sorted[mid]) / 2
+			} else {
+				r.Value = sorted[mid]
+			}
+		}, unit, nil
+
+	default:
+		return nil, "", fmt.Errorf("empty transform step")
+	}
+}
+
+// sampleWindow keeps the last size values.
+type sampleWindow struct {
+	size    int
+	samples []float64
+}
+
+func (w *sampleWindow) add(v float64) []float64 {
+	w.samples = append(w.samples, v)
+	if len(w.samples) > w.size {
+		w.samples = w.samples[len(w.samples)-w.size:]
+	}
+	return w.samples
+}
+
+type unitInfo struct {
+	quantity string
+	// factor converts a value in this unit to the base unit of its quantity.
+	factor float64
+}
+
+var linearUnits = map[string]unitInfo{
+	"B":   {"data_size", 1},
+	"kB":  {"data_size", 1e3},
+	"MB":  {"data_size", 1e6},
+	"GB":  {"data_size", 1e9},
+	"TB":  {"data_size", 1e12},
+	"KiB": {"data_size", 1 << 10},
+	"MiB": {"data_size", 1 << 20},
+	"GiB": {"data_size", 1 << 30},
+	"TiB": {"data_size", 1 << 40},
+
+	"B/s":    {"data_rate", 1},
+	"kB/s":   {"data_rate", 1e3},
+	"MB/s":   {"data_rate", 1e6},
+	"GB/s":   {"data_rate", 1e9},
+	"KiB/s":  {"data_rate", 1 << 10},
+	"MiB/s":  {"data_rate", 1 << 20},
+	"GiB/s":  {"data_rate", 1 << 30},
+	"bit/s":  {"data_rate", 1.0 / 8},
+	"kbit/s": {"data_rate", 1e3 / 8},
+	"Mbit/s": {"data_rate", 1e6 / 8},
+	"Gbit/s": {"data_rate", 1e9 / 8},
+
+	"mW": {"power", 1e-3},
+	"W":  {"power", 1},
+	"kW": {"power", 1e3},
+
+	"Wh":  {"energy", 1},
+	"kWh": {"energy", 1e3},
+
+	"Hz":  {"frequency", 1},
+	"kHz": {"frequency", 1e3},
+	"MHz": {"frequency", 1e6},
+	"GHz": {"frequency", 1e9},
+
+	"ms":  {"duration", 1e-3},
+	"s":   {"duration", 1},
+	"min": {"duration", 60},
+	"h":   {"duration", 3600},
+	"d":   {"duration", 86400},
+}
+
+// temperatureUnits convert to and from degrees Celsius.
+var temperatureUnits = map[string]struct {
+	toC   func(float64) float64
+	fromC func(float64) float64
+}{
+	"°C": {func(v float64) float64 { return v }, func(v float64) float64 { return v }},
+	"°F": {func(v float64) float64 { return (v - 32) * 5 / 9 }, func(v float64) float64 { return v*9/5 + 32 }},
+	"K":  {func(v float64) float64 { return v - 273.15 }, func(v float64) float64 { return v + 273.15 }},
+}
+
+func unitConversion(from, to string) (func(float64) float64, error) {
+	if from == "" {
+		return nil, fmt.Errorf("convert: sensor has no unit (set ha.unit)")
+	}
+
+	if tf, ok := temperatureUnits[from]; ok {
+		tt, ok := temperatureUnits[to]
+		if !ok {
+			return nil, fmt.Errorf("convert: cannot convert %s to %s", from, to)
+		}
+		return func(v float64) float64 { return tt.fromC(tf.toC(v)) }, nil
+	}
+
+	uf, ok := linearUnits[from]
+	if !ok {
+		return nil, fmt.Errorf("convert: unsupported unit %q", from)
+	}
+	ut, ok := linearUnits[to]
+	if !ok || ut.quantity != uf.quantity {
+		return nil, fmt.Errorf("convert: cannot convert %s to %s", from, to)
+	}
+
+	f := uf.factor / ut.factor
+	return func(v float64) float64 { return v * f }, nil
+}
@@ -0,0 +1,215 @@
+package sensors
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Miklakapi/gometrum/internal/config"
+)
+
+func TestUnitConversion(t *testing.T) {
+	tests := []struct {
+		from, to string
+		in, want float64
+		wantErr  bool
+	}{
+		{from: "B", to: "MB", in: 2.5e6, want: 2.5},
+		{from: "GiB", to: "MiB", in: 1.5, want: 1536},
+		{from: "kB", to: "KiB", in: 1.024, want: 1},
+		{from: "B/s", to: "Mbit/s", in: 125000, want: 1},
+		{from: "Gbit/s", to: "MB/s", in: 1, want: 125},
+		{from: "mW", to: "W", in: 1500, want: 1.5},
+		{from: "kWh", to: "Wh", in: 0.25, want: 250},
+		{from: "MHz", to: "GHz", in: 3400, want: 3.4},
+		{from: "s", to: "h", in: 5400, want: 1.5},
+		{from: "d", to: "min", in: 1, want: 1440},
+		{from: "°C", to: "°F", in: 100, want: 212},
+		{from: "°F", to: "°C", in: -40, want: -40},
+		{from: "K", to: "°C", in: 273.15, want: 0},
+		{from: "°C", to: "K", in: 25, want: 298.15},
+		{from: "°C", to: "°C", in: 42, want: 42},
+		{from: "", to: "MB", wantErr: true},
+		{from: "°C", to: "W", wantErr: true},
+		{from: "MB", to: "MB/s", wantErr: true},
+		{from: "W", to: "°C", wantErr: true},
+		{from: "bogus", to: "MB", wantErr: true},
+		{from: "MB", to: "bogus", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"_to_"+tt.to, func(t *testing.T) {
+			conv, err := unitConversion(tt.from, tt.to)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := conv(tt.in); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("%g %s = %g %s, want %g", tt.in, tt.from, got, tt.to, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	n := func(v int) *int { return &v }
+
+	tests := []struct {
+		name       string
+		transforms []config.TransformConfig
+		unit       string
+		in         []float64
+		want       []Reading
+		wantUnit   string
+	}{
+		{
+			name:       "scale and offset in order",
+			transforms: []config.TransformConfig{{Scale: f(2)}, {Offset: f(-1)}},
+			in:         []float64{3},
+			want:       []Reading{number(5, 1)},
+		},
+		{
+			name:       "convert then round",
+			transforms: []config.TransformConfig{{Convert: "GiB"}, {Round: n(2)}},
+			unit:       "MiB",
+			in:         []float64{1000},
+			want:       []Reading{{Kind: ReadingNumber, Value: 0.98, Precision: 2, Unit: "GiB"}},
+			wantUnit:   "GiB",
+		},
+		{
+			// The published precision follows the conversion, with at least
+			// two decimals instead of rounding 1.5 GiB to 2.
+			name:       "convert to a larger unit",
+			transforms: []config.TransformConfig{{Convert: "GiB"}},
+			unit:       "B",
+			in:         []float64{1610612736},
+			want:       []Reading{{Kind: ReadingNumber, Value: 1.5, Precision: 2, Unit: "GiB"}},
+			wantUnit:   "GiB",
+		},
+		{
+			name:       "convert to a smaller unit",
+			transforms: []config.TransformConfig{{Convert: "°F"}},
+			unit:       "°C",
+			in:         []float64{100},
+			want:       []Reading{{Kind: ReadingNumber, Value: 212, Precision: 1, Unit: "°F"}},
+			wantUnit:   "°F",
+		},
+		{
+			name:       "clamp",
+			transforms: []config.TransformConfig{{Min: f(0), Max: f(100)}},
+			in:         []float64{-5, 50, 120},
+			want:       []Reading{number(0, 1), number(50, 1), number(100, 1)},
+		},
+		{
+			name:       "moving average",
+			transforms: []config.TransformConfig{{Average: 3}},
+			in:         []float64{3, 6, 9, 30},
+			want:       []Reading{number(3, 1), number(4.5, 1), number(6, 1), number(15, 1)},
+		},
+		{
+			name:       "moving median",
+			transforms: []config.TransformConfig{{Median: 3}},
+			in:         []float64{10, 20, 1000, 30},
+			want:       []Reading{number(10, 1), number(15, 1), number(20, 1), number(30, 1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, unit, err := newPipeline(tt.transforms, tt.unit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unit != tt.wantUnit {
+				t.Errorf("got unit %q, want %q", unit, tt.wantUnit)
+			}
+			for i, v := range tt.in {
+				r := number(v, 1)
+				r.Unit = tt.unit
+				got := p.apply(r)
+				if want := tt.want[i]; got.Kind != want.Kind || math.Abs(got.Value-want.Value) > 1e-9 ||
+					got.Precision != want.Precision || got.Unit != want.Unit {
+					t.Errorf("sample %d: got %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineSkipsNonNumbers(t *testing.T) {
+	scale := 2.0
+	p, _, err := newPipeline([]config.TransformConfig{{Scale: &scale}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []Reading{text("ONLINE"), unavailable(), boolean(true)} {
+		if got := p.apply(r); got != r {
+			t.Errorf("apply(%+v) = %+v", r, got)
+		}
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		transforms []config.TransformConfig
+		unit       string
+	}{
+		{name: "empty step", transforms: []config.TransformConfig{{}}},
+		{name: "convert without unit", transforms: []config.TransformConfig{{Convert: "MB"}}},
+		{name: "convert across quantities", transforms: []config.TransformConfig{{Convert: "W"}}, unit: "MB"},
+		// The second step sees the unit produced by the first.
+		{name: "chained convert", transforms: []config.TransformConfig{{Convert: "°F"}, {Convert: "MB"}}, unit: "°C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := newPipeline(tt.transforms, tt.unit); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestBuildRejectsTransformsOnMixedUnits(t *testing.T) {
+	proc := t.TempDir()
+	t.Setenv("HOST_PROC", proc)
+	writeFile(t, filepath.Join(proc, "diskstats"), "   8       0 sda 1 0 1 0 1 0 1 0 0 1 0\n")
+
+	cfg := config.Config{
+		MQTT: config.MQTTConfig{DefaultInterval: time.Minute},
+		Sensors: map[string]config.SensorConfig{
+			"disk": {
+				Type:           "disk_io",
+				IncludeDevices: []string{"sda"},
+				Transforms:     []config.TransformConfig{{Convert: "MiB/s"}},
+			},
+		},
+	}
+	if err := Prepare(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Build(cfg); err == nil || !strings.Contains(err.Error(), "different units") {
+		t.Fatalf("got %v, want a mixed units error", err)
+	}
+
+	// With one unit per entry the transforms apply.
+	c := cfg.Sensors["disk"]
+	c.Metrics = []string{"read_bytes", "write_bytes"}
+	cfg.Sensors["disk"] = c
+	set, err := Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer set.Close()
+	for _, s := range set.Sensors {
+		if unit := s.HA().Unit; unit != "MiB/s" {
+			t.Errorf("%s: unit %q, want MiB/s", s.Key(), unit)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName     = "org.freedesktop.systemd1"
+	managerPath = dbus.ObjectPath("/org/freedesktop/systemd1")
+
+	managerIface = "org.freedesktop.systemd1.Manager"
+	unitIface    = "org.freedesktop.systemd1.Unit"
+	serviceIface = "org.freedesktop.systemd1.Service"
+
+	errNoSuchUnit = "org.freedesktop.systemd1.NoSuchUnit"
+)
+
+// UnitStatus is the state of a unit as reported by the systemd manager.
+type UnitStatus struct {
+	Name        string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	// Restarts is the number of automatic restarts (services only).
+	Restarts    uint32
+	HasRestarts bool
+	// StateChange is the time of the last change of ActiveState,
+	// zero if the unit never changed state.
+	StateChange time.Time
+}
+
+// Manager is the subset of the systemd D-Bus API used by GoMetrum.
+type Manager interface {
+	UnitStatus(ctx context.Context, unit string) (UnitStatus, error)
+	// StartUnit, StopUnit and RestartUnit queue a job and return its object path.
+	StartUnit(ctx context.Context, unit string) (string, error)
+	StopUnit(ctx context.Context, unit string) (string, error)
+	RestartUnit(ctx context.Context, unit string) (string, error)
+	// Connected reports whether the connection can still be used.
+	Connected() bool
+	Close() error
+}
+
+type dbusManager struct {
+	conn *dbus.Conn
+}
+
+// Connect opens a connection to the systemd manager on the system bus.
+func Connect() (Manager, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to system bus failed: %w", err)
+	}
+	return &dbusManager{conn: conn}, nil
+}
+
+func (m *dbusManager) Connected() bool { return m.conn.Connected() }
+func (m *dbusManager) Close() error    { return m.conn.Close() }
+
+func (m *dbusManager) UnitStatus(ctx context.Context, unit string) (UnitStatus, error) {
+	// GetUnit only returns loaded units. Unlike LoadUnit, it does not make
+	// systemd load the unit file again on every poll; a unit that is not
+	// loaded is stopped and unreferenced, or does not exist.
+	var path dbus.ObjectPath
+	err := m.conn.Object(busName, managerPath).
+		CallWithContext(ctx, managerIface+".GetUnit", 0, unit).
+		Store(&path)
+	if isDBusError(err, errNoSuchUnit) {
+		return UnitStatus{Name: unit, LoadState: "not-found", ActiveState: "inactive", SubState: "dead"}, nil
+	}
+	if err != nil {
+		return UnitStatus{}, fmt.Errorf("get unit failed: %w", err)
+	}
+
+	obj := m.conn.Object(busName, path)
+
+	var props map[string]dbus.Variant
+	err = obj.CallWithContext(ctx, "org.freedesktop.DBus.Properties.GetAll", 0, unitIface).Store(&props)
+	if err != nil {
+		return UnitStatus{}, fmt.Errorf("read unit properties failed: %w", err)
+	}
+
+	st := UnitStatus{Name: unit}
+	st.LoadState, _ = props["LoadState"].Value().(string)
+	st.ActiveState, _ = props["ActiveState"].Value().(string)
+	st.SubState, _ = props["SubState"].Value().(string)
+
+	// Timestamps are microseconds since the epoch, 0 if never set.
+	if usec, _ := props["StateChangeTimestamp"].Value().(uint64); usec > 0 {
+		st.StateChange = time.UnixMicro(int64(usec))
+	}
+
+	var restarts dbus.Variant
+	err = obj.CallWithContext(ctx, "org.freedesktop.DBus.Properties.Get", 0, serviceIface, "NRestarts").Store(&restarts)
+	if err == nil {
+		st.Restarts, st.HasRestarts = restarts.Value().(uint32)
+	} else if !isUnknownProperty(err) {
+		return UnitStatus{}, fmt.Errorf("read service properties failed: %w", err)
+	}
+
+	return st, nil
+}
+
+func (m *dbusManager) StartUnit(ctx context.Context, unit string) (string, error) {
+	return m.unitJob(ctx, "StartUnit", unit)
+}
+
+func (m *dbusManager) StopUnit(ctx context.Context, unit string) (string, error) {
+	return m.unitJob(ctx, "StopUnit", unit)
+}
+
+func (m *dbusManager) RestartUnit(ctx context.Context, unit string) (string, error) {
+	return m.unitJob(ctx, "RestartUnit", unit)
+}
+
+func (m *dbusManager) unitJob(ctx context.Context, method, unit string) (string, error) {
+	var job dbus.ObjectPath
+	err := m.conn.Object(busName, managerPath).
+		CallWithContext(ctx, managerIface+"."+method, 0, unit, "replace").
+		Store(&job)
+	if err != nil {
+		return "", err
+	}
+	return string(job), nil
+}
+
+// isUnknownProperty reports whether err means the property does not exist
+// for the unit, e.g. NRestarts of a timer or a mount.
+func isUnknownProperty(err error) bool {
+	return isDBusError(err,
+		"org.freedesktop.DBus.Error.UnknownProperty",
+		"org.freedesktop.DBus.Error.InvalidArgs",
+		"org.freedesktop.DBus.Error.UnknownInterface",
+	)
+}
+
+// isDBusError reports whether err is a D-Bus error with one of names.
+func isDBusError(err error, names ...string) bool {
+	var dErr dbus.Error
+	if !errors.As(err, &dErr) {
+		return false
+	}
+	return slices.Contains(names, dErr.Name)
+}
+
+// UnitName returns unit with the .service suffix added when it has
+// no unit type suffix, as systemctl does.
+func UnitName(unit string) string {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(unit, suffix) {
+			return unit
+		}
+	}
+	return unit + ".service"
+}
+
+var unitSuffixes = []string{
+	".service", ".socket", ".target", ".timer", ".mount", ".automount",
+	".path", ".slice", ".scope", ".swap", ".device",
+}